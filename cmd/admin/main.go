@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"l0/internal/cache"
+	"l0/internal/config"
+	"l0/internal/model"
+	"l0/internal/repository"
+	"l0/internal/service"
+	"log/slog"
+	"os"
+)
+
+const usage = `usage: admin <command> [flags]
+
+commands:
+  erase            anonymize delivery data of a customer or a single order
+  verify-erasures  check the hash chain of the erasure log
+  raw              print the original message an order was built from
+  rebalance        move orders to the shard the current shard map assigns
+  migrate-cache    move cached orders from legacy Redis keys into the key namespace
+  check-cache      compare cached orders with the database and optionally repair them
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	storage, err := repository.New(cfg)
+	if err != nil {
+		log.Error("failed to init storage", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	backend, err := cache.Open(cfg.Redis)
+	if err != nil {
+		log.Error("failed to init cache", slog.Any("error", err))
+		os.Exit(1)
+	}
+	orders := cache.NewOrders(backend, cfg.Redis)
+
+	// Repairs may be queued under write-behind; they are flushed before exit.
+	writerCtx, stopWriter := context.WithCancel(context.Background())
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		orders.RunWriteBehind(writerCtx)
+	}()
+
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "erase":
+		err = runErase(service.NewErasureService(storage, orders, nil), args)
+	case "verify-erasures":
+		err = runVerifyErasures(service.NewErasureService(storage, orders, nil))
+	case "raw":
+		err = runRaw(service.New(storage, orders, nil), args)
+	case "rebalance":
+		err = runRebalance(storage, args)
+	case "migrate-cache":
+		err = runMigrateCache(orders, args)
+	case "check-cache":
+		err = runCheckCache(cache.NewCacheService(orders, storage, cache.WithLogger(log)), args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	stopWriter()
+	<-writerDone
+
+	if err != nil {
+		log.Error("command failed", slog.String("command", cmd), slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func runErase(erasures *service.ErasureService, args []string) error {
+	fs := flag.NewFlagSet("erase", flag.ExitOnError)
+	customerID := fs.String("customer", "", "customer_id whose orders should be anonymized")
+	orderUID := fs.String("order", "", "order_uid of a single order to anonymize")
+	requestedBy := fs.String("by", os.Getenv("USER"), "operator recorded in the erasure log")
+	fs.Parse(args)
+
+	if (*customerID == "") == (*orderUID == "") {
+		return fmt.Errorf("exactly one of -customer or -order is required")
+	}
+
+	req := model.ErasureRequest{SubjectType: model.SubjectCustomer, SubjectID: *customerID, RequestedBy: *requestedBy}
+	if *orderUID != "" {
+		req = model.ErasureRequest{SubjectType: model.SubjectOrder, SubjectID: *orderUID, RequestedBy: *requestedBy}
+	}
+
+	req.Source = model.SourceAdmin
+
+	record, err := erasures.Erase(req)
+	if record.ID != 0 {
+		printJSON(record)
+	}
+	return err
+}
+
+func runVerifyErasures(erasures *service.ErasureService) error {
+	verified, err := erasures.VerifyLog()
+	fmt.Printf("verified %d erasure log entries\n", verified)
+	return err
+}
+
+func runRaw(orders *service.OrderService, args []string) error {
+	fs := flag.NewFlagSet("raw", flag.ExitOnError)
+	id := fs.String("id", "", "order_uid")
+	meta := fs.Bool("meta", false, "print NATS metadata along with the payload")
+	fs.Parse(args)
+
+	if *id == "" {
+		return fmt.Errorf("-id is required")
+	}
+
+	raw, err := orders.GetRawOrder(*id)
+	if err != nil {
+		return err
+	}
+
+	if *meta {
+		printJSON(raw)
+		return nil
+	}
+	printJSON(raw.Payload)
+	return nil
+}
+
+func runRebalance(storage repository.Repository, args []string) error {
+	fs := flag.NewFlagSet("rebalance", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only list the orders that would move")
+	fs.Parse(args)
+
+	sharded, ok := storage.(*repository.ShardedStorage)
+	if !ok {
+		return fmt.Errorf("sharding is not configured")
+	}
+
+	moved, err := sharded.Rebalance(context.Background(), *dryRun, func(m repository.RebalanceMove) {
+		fmt.Printf("%s: shard %d -> %d\n", m.OrderUID, m.From, m.To)
+	})
+	if *dryRun {
+		fmt.Printf("%d orders would move\n", moved)
+	} else {
+		fmt.Printf("moved %d orders\n", moved)
+	}
+	return err
+}
+
+func runMigrateCache(orders *cache.Orders, args []string) error {
+	fs := flag.NewFlagSet("migrate-cache", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only list the keys that would move")
+	fs.Parse(args)
+
+	redisCache, ok := orders.Backend().(*cache.Redis)
+	if !ok {
+		return fmt.Errorf("the cache backend is not redis")
+	}
+
+	moved, err := redisCache.MigrateKeys(context.Background(), orders.Keys(), *dryRun, func(m cache.KeyMove) {
+		fmt.Printf("%s -> %s\n", m.From, m.To)
+	})
+	if *dryRun {
+		fmt.Printf("%d keys would move\n", moved)
+	} else {
+		fmt.Printf("moved %d keys\n", moved)
+	}
+	return err
+}
+
+func runCheckCache(caches *cache.CacheService, args []string) error {
+	fs := flag.NewFlagSet("check-cache", flag.ExitOnError)
+	sample := fs.Float64("sample", 1, "fraction of orders and cached keys to check, e.g. 0.05")
+	rate := fs.Float64("rate", 500, "orders read and keys checked per second, 0 for no limit")
+	repair := fs.Bool("repair", false, "add missing, rewrite stale and delete orphaned entries")
+	fs.Parse(args)
+
+	if *sample <= 0 || *sample > 1 {
+		return fmt.Errorf("-sample must be in (0, 1]")
+	}
+
+	report, err := caches.Check(context.Background(), cache.CheckOptions{Sample: *sample, Rate: *rate, Repair: *repair}, func(f cache.Finding) {
+		state := ""
+		if f.Repaired {
+			state = " (repaired)"
+		}
+		fmt.Printf("%s %s%s\n", f.Kind, f.OrderUID, state)
+		for _, c := range f.Changes {
+			fmt.Printf("  %s: %v -> %v\n", c.Path, c.Old, c.New)
+		}
+	})
+	printJSON(report)
+	return err
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
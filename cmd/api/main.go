@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"l0/internal/cache"
 	"l0/internal/config"
+	"l0/internal/http-server/handlers/cachestats"
+	"l0/internal/http-server/handlers/erasure"
 	"l0/internal/http-server/handlers/order"
 	"l0/internal/repository"
 	"l0/internal/service"
@@ -32,14 +35,40 @@ func main() {
 	log := setupLogger(cfg.Env)
 	log.Info("starting api server", slog.String("env", cfg.Env))
 
-	redis := cache.New(cfg.Redis)
-	storage, err := repository.Connect(cfg.Database)
+	backend, err := cache.Open(cfg.Redis)
+	if err != nil {
+		log.Error("failed to init cache", slog.Any("error", err))
+		os.Exit(1)
+	}
+	orders := cache.NewOrders(backend, cfg.Redis)
+
+	storage, err := repository.New(cfg)
 	if err != nil {
 		log.Error("failed to init storage", slog.Any("error", err))
 		os.Exit(1)
 	}
 
-	orderService := service.New(storage, redis)
+	local := service.NewLocalCache(cfg.LocalCache)
+	orderService := service.New(storage, orders, local)
+	erasureService := service.NewErasureService(storage, orders, local)
+
+	// Background cache work stops after the server, so writes queued by the
+	// last requests are still flushed.
+	cacheCtx, stopCache := context.WithCancel(context.Background())
+	defer stopCache()
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		orders.RunWriteBehind(cacheCtx)
+	}()
+	if local != nil {
+		go func() {
+			if err := orderService.WatchInvalidations(cacheCtx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Error("cache invalidation watch stopped", slog.Any("error", err))
+			}
+		}()
+	}
 
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
@@ -49,6 +78,25 @@ func main() {
 
 	router.Route("/order", func(r chi.Router) {
 		r.Get("/{id}", order.GetOrder(log, orderService))
+		r.Get("/{id}/history", order.GetOrderHistory(log, orderService))
+		r.Get("/{id}/raw", order.GetRawOrder(log, orderService))
+		r.Get("/{id}/status", order.GetStatusHistory(log, orderService))
+		r.Patch("/{id}/status", order.ChangeStatus(log, orderService))
+	})
+
+	router.Route("/orders", func(r chi.Router) {
+		r.Get("/", order.ListOrders(log, orderService))
+		r.Get("/search", order.SearchOrders(log, orderService))
+	})
+
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.BasicAuth("admin", map[string]string{
+			cfg.HTTPServer.User: cfg.HTTPServer.Password,
+		}))
+
+		r.Post("/erasures", erasure.Erase(log, erasureService))
+		r.Get("/erasures/verify", erasure.Verify(log, erasureService))
+		r.Get("/cache/stats", cachestats.Get(log, orderService))
 	})
 
 	c := cors.New(cors.Options{
@@ -95,6 +143,9 @@ func main() {
 		}
 	}
 
+	stopCache()
+	<-writerDone
+
 	log.Info("server stopped")
 }
 
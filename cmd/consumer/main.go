@@ -3,16 +3,19 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"l0/internal/cache"
 	"l0/internal/config"
 	"l0/internal/model"
 	_nats "l0/internal/pkg/nats"
 	"l0/internal/repository"
+	"l0/internal/service"
 
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -26,23 +29,41 @@ func main() {
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	log.Info("starting consumer")
 
-	storage, err := repository.Connect(cfg.Database)
+	storage, err := repository.New(cfg)
 	if err != nil {
 		log.Error("failed to init storage", slog.Any("error", err))
 		os.Exit(1)
 	}
 
-	redisCache := cache.New(cfg.Redis)
-	cacheService := cache.NewCacheService(redisCache, storage, cache.WithLogger(log))
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	if err := cacheService.RestoreCache(ctx); err != nil {
-		log.Error("failed to restore cache", slog.Any("error", err))
-		cancel()
+	backend, err := cache.Open(cfg.Redis)
+	if err != nil {
+		log.Error("failed to init cache", slog.Any("error", err))
 		os.Exit(1)
 	}
-	cancel()
-	log.Info("cache restored successfully")
+	orders := cache.NewOrders(backend, cfg.Redis)
+	cacheService := cache.NewCacheService(orders, storage,
+		cache.WithLogger(log),
+		cache.WithReconcileLookback(cfg.Redis.Reconcile.Lookback),
+	)
+
+	writerCtx, stopWriter := context.WithCancel(context.Background())
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		orders.RunWriteBehind(writerCtx)
+	}()
+
+	// Warmup runs alongside consumption: new messages are not held back by
+	// it, and it never overwrites what the consumer has cached.
+	warmupCtx, stopWarmup := context.WithCancel(context.Background())
+	defer stopWarmup()
+	if !orders.ReadOnly() {
+		go func() {
+			if err := cacheService.Warmup(warmupCtx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Error("cache warmup failed", slog.Any("error", err))
+			}
+		}()
+	}
 
 	nc, err := _nats.New(cfg.NatsStreaming, "consumer")
 	if err != nil {
@@ -55,7 +76,12 @@ func main() {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	var wg sync.WaitGroup
-	_, cancel = context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if interval := cfg.Redis.Reconcile.Interval; interval > 0 && !orders.ReadOnly() {
+		syncService := service.NewSyncService(cacheService, log, cfg.Redis.Reconcile.Timeout)
+		go syncService.StartPeriodicSync(ctx, interval)
+	}
 
 	sub, err := nc.Consume("l0", func(msg *stan.Msg) {
 		wg.Add(1)
@@ -63,16 +89,38 @@ func main() {
 
 		log.Info("received message", slog.String("data", string(msg.Data)))
 
+		// Messages that can never be stored are acknowledged, or NATS would
+		// redeliver them forever.
 		var order model.Order
 		if err := json.Unmarshal(msg.Data, &order); err != nil {
 			log.Error("failed to unmarshal message",
 				slog.Any("error", err),
 				slog.String("data", string(msg.Data)),
 			)
+			ack(log, msg)
+			return
+		}
+		if err := order.Validate(); err != nil {
+			log.Error("invalid order",
+				slog.Any("error", err),
+				slog.String("order_id", order.OrderUID),
+			)
+			ack(log, msg)
 			return
 		}
 
-		if err := storage.AddOrder(order); err != nil {
+		src := model.ChangeSource{
+			Kind: model.SourceNATS,
+			Ref:  strconv.FormatUint(msg.Sequence, 10),
+			Raw: &model.RawOrder{
+				OrderUID:    order.OrderUID,
+				Subject:     msg.Subject,
+				Sequence:    msg.Sequence,
+				PublishedAt: time.Unix(0, msg.Timestamp),
+				Payload:     msg.Data,
+			},
+		}
+		if err := storage.AddOrder(order, src); err != nil {
 			log.Error("failed to save order to database",
 				slog.Any("error", err),
 				slog.String("order_id", order.OrderUID),
@@ -80,24 +128,29 @@ func main() {
 			return
 		}
 
-		if err := redisCache.Set(order.OrderUID, order); err != nil {
-			log.Error("failed to save order to redis",
+		// The stored order carries the status, which the message does not.
+		saved, err := storage.GetOrderById(order.OrderUID)
+		if err != nil {
+			log.Error("failed to read back saved order",
 				slog.Any("error", err),
 				slog.String("order_id", order.OrderUID),
 			)
 			return
 		}
 
-		log.Info("order processed successfully",
-			slog.String("order_id", order.OrderUID),
-		)
-
-		if err := msg.Ack(); err != nil {
-			log.Error("failed to acknowledge message",
+		if err := orders.Set(context.Background(), saved); err != nil {
+			log.Error("failed to save order to cache",
 				slog.Any("error", err),
 				slog.String("order_id", order.OrderUID),
 			)
+			return
 		}
+
+		log.Info("order processed successfully",
+			slog.String("order_id", order.OrderUID),
+		)
+
+		ack(log, msg)
 	}, stan.DurableName("my-durable"), stan.SetManualAckMode())
 
 	if err != nil {
@@ -111,6 +164,7 @@ func main() {
 	log.Info("starting graceful shutdown")
 
 	cancel()
+	stopWarmup()
 
 	if err := sub.Unsubscribe(); err != nil {
 		log.Error("failed to unsubscribe", slog.Any("error", err))
@@ -129,5 +183,19 @@ func main() {
 		log.Warn("shutdown timeout exceeded")
 	}
 
+	// Queued cache writes are flushed only after the last message is handled.
+	stopWriter()
+	<-writerDone
+	log.Info("cache writes", slog.Any("stats", orders.WriteStats()))
+
 	log.Info("consumer stopped")
 }
+
+func ack(log *slog.Logger, msg *stan.Msg) {
+	if err := msg.Ack(); err != nil {
+		log.Error("failed to acknowledge message",
+			slog.Any("error", err),
+			slog.Uint64("sequence", msg.Sequence),
+		)
+	}
+}
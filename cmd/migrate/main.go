@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"l0/internal/config"
+	"l0/internal/repository"
+	"log/slog"
+	"os"
+)
+
+const usage = `usage: migrate <command> [args]
+
+commands:
+  up                   apply all pending migrations
+  down                 roll back the latest migration
+  status               print the status of every migration
+  redo                 roll back the latest migration and apply it again
+  version              print the current schema version
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "up", "down", "status", "redo", "version":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	if cfg.Database.Driver == repository.DriverSQLite {
+		db, err := repository.OpenSQLite(cfg.StoragePath)
+		if err != nil {
+			log.Error("failed to open database", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		err = repository.Migrate(db, repository.DriverSQLite, command, args...)
+		db.Close()
+		if err != nil {
+			log.Error("migration failed", slog.String("command", command), slog.Any("error", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	for i, dbCfg := range repository.Databases(cfg) {
+		log := log.With(slog.Int("shard", i))
+
+		db, err := repository.Open(dbCfg)
+		if err != nil {
+			log.Error("failed to connect to database", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		err = repository.Migrate(db, repository.DriverPostgres, command, args...)
+		db.Close()
+		if err != nil {
+			log.Error("migration failed", slog.String("command", command), slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"l0/internal/config"
+	"time"
+)
+
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendNone   = "none"
+)
+
+// ErrMiss is returned by Get when the key does not exist or has expired.
+var ErrMiss = errors.New("cache: miss")
+
+// Cache is a byte-level key-value store with expirations. Values are opaque
+// to it: encoding and key layout belong to the callers. A zero TTL keeps the
+// key forever.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// MGet returns one value per key, nil for misses.
+	MGet(ctx context.Context, keys ...string) ([][]byte, error)
+	MSet(ctx context.Context, items ...Item) error
+	// Scan calls fn with batches of the keys that start with prefix.
+	Scan(ctx context.Context, prefix string, fn func(keys []string) error) error
+}
+
+type Item struct {
+	Key   string
+	Value []byte
+	TTL   time.Duration
+	// IfAbsent keeps a value that is already there.
+	IfAbsent bool
+}
+
+// Refresher is implemented by backends that can read a key and renew its
+// expiration in one step.
+type Refresher interface {
+	GetEx(ctx context.Context, key string, ttl time.Duration) ([]byte, error)
+}
+
+// Locker is implemented by backends shared between processes. TryLock
+// returns a nil unlock function when somebody else holds the lock.
+type Locker interface {
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), err error)
+}
+
+// PubSub is implemented by backends that can broadcast messages to every
+// process sharing them. Subscribe blocks until ctx is done; onSubscribe is
+// called each time the subscription is (re)established, since messages sent
+// while it was down are lost.
+type PubSub interface {
+	Publish(ctx context.Context, channel string, msg []byte) error
+	Subscribe(ctx context.Context, channel string, onSubscribe func(), fn func(msg []byte)) error
+}
+
+// Indexer is implemented by backends with sorted sets, which hold the
+// secondary indexes of orders.
+type Indexer interface {
+	// Reindex applies each update atomically, so readers never see an order
+	// in both its old and new indexes or in neither.
+	Reindex(ctx context.Context, updates ...IndexUpdate) error
+	// Unindex removes each Member from the indexes its Record lists and
+	// deletes the Record.
+	Unindex(ctx context.Context, updates ...IndexUpdate) error
+	// IndexRange returns the members of key scored within [lo, hi], lowest
+	// score first or, with reverse, highest first. A zero limit means all.
+	IndexRange(ctx context.Context, key string, lo, hi float64, reverse bool, offset, limit int) ([]string, error)
+}
+
+// IndexUpdate puts Member, scored Score, into the index Keys and takes it
+// out of the other indexes listed in Record, then lists Keys in Record.
+// With IfAbsent nothing happens when Record exists. Members of Keys scored
+// below Prune are dropped. Keys expire after KeyTTL and Record after TTL;
+// zero TTLs never expire.
+type IndexUpdate struct {
+	Record   string
+	Member   string
+	Score    float64
+	Keys     []string
+	IfAbsent bool
+	Prune    float64
+	KeyTTL   time.Duration
+	TTL      time.Duration
+}
+
+// Open returns the backend selected by cfg.Backend.
+func Open(cfg config.Redis) (Cache, error) {
+	if _, err := CodecByName(cfg.Codec); err != nil {
+		return nil, err
+	}
+	if err := checkPolicy(cfg.Write.Policy); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Backend {
+	case BackendRedis, "":
+		return New(cfg)
+	case BackendMemory:
+		return NewMemory(), nil
+	case BackendNone:
+		return Noop{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+}
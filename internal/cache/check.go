@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"l0/internal/domain"
+	"l0/internal/lib/diff"
+	"l0/internal/model"
+	"l0/internal/repository"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	FindingMissing  = "missing"
+	FindingStale    = "stale"
+	FindingOrphaned = "orphaned"
+)
+
+// CheckOptions tune a consistency check. Sample is the fraction of orders
+// and cached keys looked at, 0 or 1 meaning all of them; orders are sampled
+// by the database, so the others are never read. Rate caps the orders read
+// and the keys checked per second so that a check can run against
+// production; 0 means no limit.
+type CheckOptions struct {
+	Sample float64
+	Rate   float64
+	Repair bool
+}
+
+// Finding is one order on which the cache and the database disagree.
+// Changes lists the differing fields of a stale entry, with the cached value
+// as old and the database one as new.
+type Finding struct {
+	Kind     string        `json:"kind"`
+	OrderUID string        `json:"order_uid"`
+	Changes  []diff.Change `json:"changes,omitempty"`
+	Repaired bool          `json:"repaired,omitempty"`
+}
+
+type CheckReport struct {
+	Checked   int           `json:"checked"`
+	Keys      int           `json:"keys"`
+	Missing   int           `json:"missing"`
+	Stale     int           `json:"stale"`
+	Orphaned  int           `json:"orphaned"`
+	Repaired  int           `json:"repaired"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Check compares the cache with the database and reports every finding to
+// report as soon as it is made. Unlike Reconcile it compares decoded orders
+// field by field, so an entry written by another codec is not stale, and it
+// changes nothing unless asked to repair.
+func (cs *CacheService) Check(ctx context.Context, opts CheckOptions, report func(Finding)) (CheckReport, error) {
+	c := checker{cs: cs, opts: opts, report: report}
+	if opts.Rate > 0 {
+		c.limiter = rate.NewLimiter(rate.Limit(opts.Rate), max(int(opts.Rate), 1))
+	}
+	if opts.Repair && cs.orders.ReadOnly() {
+		return CheckReport{}, errors.New("cannot repair: the cache is read-only for this process")
+	}
+
+	c.stats.StartedAt = time.Now()
+	err := c.run(ctx)
+	c.stats.Duration = time.Since(c.stats.StartedAt)
+	return c.stats, err
+}
+
+type checker struct {
+	cs      *CacheService
+	opts    CheckOptions
+	report  func(Finding)
+	limiter *rate.Limiter
+	stats   CheckReport
+}
+
+// pageSize keeps each database read within what the rate allows.
+func (c *checker) pageSize() int {
+	if c.limiter == nil {
+		return c.cs.batchSize
+	}
+	return min(c.cs.batchSize, c.limiter.Burst())
+}
+
+func (c *checker) sampled() bool {
+	return c.opts.Sample <= 0 || c.opts.Sample >= 1 || rand.Float64() < c.opts.Sample
+}
+
+// wait blocks until n more orders or keys may be checked.
+func (c *checker) wait(ctx context.Context, n int) error {
+	if c.limiter == nil {
+		return nil
+	}
+	for n > 0 {
+		chunk := min(n, c.limiter.Burst())
+		if err := c.limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+func (c *checker) run(ctx context.Context) error {
+	var cursor repository.OrderCursor
+	for {
+		// Each page is paid for before the next one is read.
+		page, err := c.cs.storage.SampleOrdersNewestFirst(ctx, cursor, c.pageSize(), c.opts.Sample)
+		if err != nil {
+			return fmt.Errorf("failed to read orders: %w", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		cursor = repository.CursorAt(page[len(page)-1])
+
+		if err := c.checkOrders(ctx, page); err != nil {
+			return err
+		}
+	}
+
+	err := c.cs.orders.cache.Scan(ctx, c.cs.orders.keys.Prefix(KindOrder), func(keys []string) error {
+		var sample []string
+		for _, key := range keys {
+			if c.sampled() {
+				sample = append(sample, key)
+			}
+		}
+		for len(sample) > 0 {
+			n := min(len(sample), c.cs.batchSize)
+			if err := c.checkKeys(ctx, sample[:n]); err != nil {
+				return err
+			}
+			sample = sample[n:]
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan cached orders: %w", err)
+	}
+	return nil
+}
+
+func (c *checker) checkOrders(ctx context.Context, orders []model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	if err := c.wait(ctx, len(orders)); err != nil {
+		return err
+	}
+
+	ids := make([]string, len(orders))
+	for i, o := range orders {
+		ids[i] = o.OrderUID
+	}
+	cached, err := c.cs.orders.GetMany(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to read cached orders: %w", err)
+	}
+
+	for _, order := range orders {
+		c.stats.Checked++
+
+		entry, ok := cached[order.OrderUID]
+		if !ok {
+			f := Finding{Kind: FindingMissing, OrderUID: order.OrderUID}
+			if c.opts.Repair {
+				if err := c.cs.orders.Warm(ctx, []model.Order{order}); err != nil {
+					return fmt.Errorf("failed to add order %s: %w", order.OrderUID, err)
+				}
+				f.Repaired = true
+			}
+			c.found(f)
+			continue
+		}
+
+		changes, err := diff.Compare(entry, order)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		// The page may be older than the cached entry: confirm against a
+		// fresh read before calling the entry stale.
+		fresh, err := c.cs.storage.GetOrderById(order.OrderUID)
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reload order %s: %w", order.OrderUID, err)
+		}
+		if changes, err = diff.Compare(entry, fresh); err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		f := Finding{Kind: FindingStale, OrderUID: order.OrderUID, Changes: changes}
+		if c.opts.Repair {
+			if err := c.cs.orders.Set(ctx, fresh); err != nil {
+				return fmt.Errorf("failed to rewrite order %s: %w", order.OrderUID, err)
+			}
+			f.Repaired = true
+		}
+		c.found(f)
+	}
+	return nil
+}
+
+func (c *checker) checkKeys(ctx context.Context, keys []string) error {
+	if err := c.wait(ctx, len(keys)); err != nil {
+		return err
+	}
+	c.stats.Keys += len(keys)
+
+	orphans, err := c.cs.orphaned(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range orphans {
+		f := Finding{Kind: FindingOrphaned, OrderUID: id}
+		if c.opts.Repair {
+			if err := c.cs.orders.Remove(ctx, id); err != nil {
+				return fmt.Errorf("failed to delete order %s: %w", id, err)
+			}
+			f.Repaired = true
+		}
+		c.found(f)
+	}
+	return nil
+}
+
+func (c *checker) found(f Finding) {
+	switch f.Kind {
+	case FindingMissing:
+		c.stats.Missing++
+	case FindingStale:
+		c.stats.Stale++
+	case FindingOrphaned:
+		c.stats.Orphaned++
+	}
+	if f.Repaired {
+		c.stats.Repaired++
+	}
+	if c.report != nil {
+		c.report(f)
+	}
+}
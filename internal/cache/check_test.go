@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"l0/internal/model"
+	"l0/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReportsAndRepairs(t *testing.T) {
+	ctx := context.Background()
+	orders := NewOrders(NewMemory(), testRedisConfig())
+
+	storage := &reconcileStorage{}
+	for _, uid := range []string{"a", "b", "c"} {
+		storage.orders = append(storage.orders, testOrder(uid))
+	}
+
+	require.NoError(t, orders.Set(ctx, storage.orders[0]))
+	stale := testOrder("b")
+	stale.TrackNumber = "outdated"
+	require.NoError(t, orders.Set(ctx, stale))
+	require.NoError(t, orders.Set(ctx, testOrder("gone")))
+
+	cs := NewCacheService(orders, storage, WithBatchSize(2))
+
+	var findings []Finding
+	report, err := cs.Check(ctx, CheckOptions{}, func(f Finding) { findings = append(findings, f) })
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, 3, report.Keys)
+	assert.Equal(t, 1, report.Missing)
+	assert.Equal(t, 1, report.Stale)
+	assert.Equal(t, 1, report.Orphaned)
+	assert.Zero(t, report.Repaired)
+
+	require.Len(t, findings, 3)
+	assert.Equal(t, Finding{Kind: FindingStale, OrderUID: "b", Changes: findings[0].Changes}, findings[0])
+	require.Len(t, findings[0].Changes, 1)
+	assert.Equal(t, "track_number", findings[0].Changes[0].Path)
+	assert.Equal(t, "outdated", findings[0].Changes[0].Old)
+
+	// Checking alone changes nothing.
+	cached, err := orders.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "outdated", cached.TrackNumber)
+
+	report, err = cs.Check(ctx, CheckOptions{Repair: true, Rate: 1000}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Repaired)
+
+	report, err = cs.Check(ctx, CheckOptions{}, nil)
+	require.NoError(t, err)
+	assert.Zero(t, report.Missing+report.Stale+report.Orphaned)
+}
+
+// sampleStorage records how check-cache reads the orders.
+type sampleStorage struct {
+	reconcileStorage
+	limits    []int
+	fractions []float64
+}
+
+func (s *sampleStorage) SampleOrdersNewestFirst(ctx context.Context, after repository.OrderCursor, limit int, fraction float64) ([]model.Order, error) {
+	s.limits = append(s.limits, limit)
+	s.fractions = append(s.fractions, fraction)
+	return s.GetOrdersNewestFirst(ctx, after, limit)
+}
+
+func TestCheckSamplesAndThrottlesReads(t *testing.T) {
+	ctx := context.Background()
+	storage := &sampleStorage{}
+	for _, uid := range []string{"a", "b", "c", "d"} {
+		storage.orders = append(storage.orders, testOrder(uid))
+	}
+
+	cs := NewCacheService(NewOrders(NewMemory(), testRedisConfig()), storage)
+	report, err := cs.Check(ctx, CheckOptions{Sample: 0.5, Rate: 3}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Checked)
+
+	// The database draws the sample, and no page is larger than a second
+	// of the rate.
+	assert.Equal(t, []int{3, 3, 3}, storage.limits)
+	assert.Equal(t, []float64{0.5, 0.5, 0.5}, storage.fractions)
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"l0/internal/domain"
+	"l0/internal/model"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Coalescer makes sure a missing order is loaded from the database once,
+// no matter how many requests miss it at the same time: concurrent callers
+// in this process share one load, and with the lock enabled on a shared
+// backend replicas wait for the one that holds the lock to fill the cache.
+type Coalescer struct {
+	orders *Orders
+	group  singleflight.Group
+}
+
+func NewCoalescer(orders *Orders) *Coalescer {
+	return &Coalescer{orders: orders}
+}
+
+// Order returns the order loaded by load and caches it. shared
+// reports whether the result came from another caller's load.
+func (c *Coalescer) Order(id string, load func() (model.Order, error)) (order model.Order, shared bool, err error) {
+	v, err, shared := c.group.Do(id, func() (interface{}, error) {
+		return c.load(id, load)
+	})
+	if err != nil {
+		return model.Order{}, shared, err
+	}
+	return v.(model.Order), shared, nil
+}
+
+func (c *Coalescer) load(id string, load func() (model.Order, error)) (model.Order, error) {
+	cfg := c.orders.stampede
+	ctx := context.Background()
+
+	if missing, _ := c.orders.IsMissing(ctx, id); missing {
+		return model.Order{}, fmt.Errorf("negative cache: %w", domain.ErrOrderNotFound)
+	}
+
+	// Read-only replicas never fill the cache: taking the lock would only
+	// make other replicas wait for a value that is not coming, and Set would
+	// evict the copies the writers cached.
+	if c.orders.ReadOnly() {
+		return load()
+	}
+
+	if locker, ok := c.orders.cache.(Locker); ok && cfg.Lock {
+		unlock, err := locker.TryLock(ctx, c.orders.keys.Lock(KindOrder, id), cfg.LockTTL)
+		switch {
+		case err != nil:
+			// Without the backend there is nothing to coordinate on; load anyway.
+		case unlock != nil:
+			defer unlock()
+		default:
+			order, missing, ok := c.wait(ctx, id, cfg.Wait, cfg.PollInterval)
+			if missing {
+				return model.Order{}, fmt.Errorf("negative cache: %w", domain.ErrOrderNotFound)
+			}
+			if ok {
+				return order, nil
+			}
+		}
+	}
+
+	order, err := load()
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		_ = c.orders.SetMissing(ctx, id)
+	}
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	// A failed write only costs the next request another load. The order
+	// did not change, so the replicas are not told to drop their copies.
+	_ = c.orders.Fill(ctx, order)
+	return order, nil
+}
+
+// wait polls the cache until another replica has cached the order, or has
+// found out that it does not exist, or the wait runs out.
+func (c *Coalescer) wait(ctx context.Context, id string, wait, poll time.Duration) (order model.Order, missing, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return model.Order{}, false, false
+		case <-ticker.C:
+			order, err := c.orders.cache.Get(ctx, c.orders.keys.Order(id))
+			if err == nil {
+				var o model.Order
+				if Decode(order, &o) == nil {
+					return o, false, true
+				}
+			}
+			if errors.Is(err, ErrMiss) {
+				if missing, _ := c.orders.IsMissing(ctx, id); missing {
+					return model.Order{}, true, false
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"l0/internal/config"
+	"l0/internal/domain"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRedisConfig() config.Redis {
+	return config.Redis{
+		KeyPrefix:  "l0",
+		KeyVersion: "v1",
+		TTL:        config.CacheTTL{Default: time.Hour, Missing: time.Minute},
+		Stampede:   config.Stampede{Lock: true, LockTTL: time.Second, Wait: time.Second, PollInterval: 5 * time.Millisecond},
+	}
+}
+
+func testOrder(id string) model.Order {
+	return model.Order{OrderUID: id, Payment: model.Payment{Currency: model.CurrencyUSD}}
+}
+
+func TestCoalescerSharesOneLoad(t *testing.T) {
+	c := NewCoalescer(NewOrders(NewMemory(), testRedisConfig()))
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() (model.Order, error) {
+		loads.Add(1)
+		<-release
+		return testOrder("a"), nil
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	var started sync.WaitGroup
+	started.Add(callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			order, _, err := c.Order("a", load)
+			require.NoError(t, err)
+			assert.Equal(t, "a", order.OrderUID)
+		}()
+	}
+
+	started.Wait()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+}
+
+func TestCoalescerLockAcrossReplicas(t *testing.T) {
+	// Two replicas share the backend but not the in-process group.
+	shared := NewMemory()
+	first := NewCoalescer(NewOrders(shared, testRedisConfig()))
+	second := NewCoalescer(NewOrders(shared, testRedisConfig()))
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, err := first.Order("a", func() (model.Order, error) {
+			close(loading)
+			<-release
+			return testOrder("a"), nil
+		})
+		assert.NoError(t, err)
+	}()
+
+	<-loading
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	order, _, err := second.Order("a", func() (model.Order, error) {
+		t.Error("second replica must wait for the first one")
+		return model.Order{}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a", order.OrderUID)
+	<-done
+}
+
+func TestCoalescerCachesNotFound(t *testing.T) {
+	orders := NewOrders(NewMemory(), testRedisConfig())
+	c := NewCoalescer(orders)
+
+	var loads atomic.Int32
+	load := func() (model.Order, error) {
+		loads.Add(1)
+		return model.Order{}, fmt.Errorf("storage: %w", domain.ErrOrderNotFound)
+	}
+
+	_, _, err := c.Order("missing", load)
+	assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+	_, _, err = c.Order("missing", load)
+	assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+
+	assert.Equal(t, int32(1), loads.Load())
+	assert.Equal(t, int64(1), orders.NegativeHits())
+}
+
+func TestCoalescerReadOnlyLeavesCacheAlone(t *testing.T) {
+	ctx := context.Background()
+	shared := NewMemory()
+	writer := NewOrders(shared, testRedisConfig())
+	cfg := testRedisConfig()
+	cfg.Write.Policy = PolicyReadOnly
+	c := NewCoalescer(NewOrders(shared, cfg))
+
+	cached := testOrder("a")
+	cached.TrackNumber = "cached"
+	require.NoError(t, writer.Set(ctx, cached))
+
+	// Memory delivers synchronously, on the publishing goroutine.
+	var published []string
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	subscribed := make(chan struct{})
+	go shared.Subscribe(subCtx, writer.Keys().Invalidations(), func() { close(subscribed) }, func(msg []byte) {
+		published = append(published, string(msg))
+	})
+	<-subscribed
+
+	// A writer holding the lock must not make the read-only replica wait.
+	unlock, err := shared.TryLock(ctx, writer.Keys().Lock(KindOrder, "b"), time.Minute)
+	require.NoError(t, err)
+	defer unlock()
+
+	start := time.Now()
+	order, _, err := c.Order("b", func() (model.Order, error) { return testOrder("b"), nil })
+	require.NoError(t, err)
+	assert.Equal(t, "b", order.OrderUID)
+	assert.Less(t, time.Since(start), cfg.Stampede.Wait)
+
+	_, _, err = c.Order("a", func() (model.Order, error) { return testOrder("a"), nil })
+	require.NoError(t, err)
+
+	got, err := writer.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "cached", got.TrackNumber, "a read miss must not evict the writers' copy")
+	_, err = writer.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss)
+	assert.Empty(t, published)
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"l0/internal/model"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	CodecJSON    = "json"
+	CodecGzip    = "gzip"
+	CodecZstd    = "zstd"
+	CodecMsgpack = "msgpack"
+)
+
+// Header bytes of encoded values. A header names both the codec and the
+// version of its format, so a format change gets a new byte and entries of
+// every kind can be read side by side while a rollout is in progress. JSON
+// values carry no header: they start with '{', which no header uses, and
+// this keeps them readable by releases that predate codecs.
+const (
+	headerGzipJSON  byte = 0x01
+	headerZstdJSON  byte = 0x02
+	headerMsgpackV1 byte = 0x03
+)
+
+var ErrUnknownCodec = errors.New("cache: unknown codec")
+
+// headers is the first byte of each codec's output.
+var headers = map[string]byte{
+	CodecJSON:    '{',
+	CodecGzip:    headerGzipJSON,
+	CodecZstd:    headerZstdJSON,
+	CodecMsgpack: headerMsgpackV1,
+}
+
+// encodedBy reports whether data was written by c in its current format.
+func encodedBy(c Codec, data []byte) bool {
+	h, ok := headers[c.Name()]
+	return ok && len(data) > 0 && data[0] == h
+}
+
+// Codec turns cached values into bytes and back. Encode output includes the
+// header, so any codec's output can be given to Decode.
+type Codec interface {
+	Name() string
+	Encode(v any) ([]byte, error)
+}
+
+var codecs = map[string]Codec{
+	CodecJSON:    jsonCodec{},
+	CodecGzip:    &gzipCodec{},
+	CodecZstd:    newZstdCodec(),
+	CodecMsgpack: msgpackCodec{},
+}
+
+// CodecByName returns the codec selected by redis.codec; an empty name is
+// JSON.
+func CodecByName(name string) (Codec, error) {
+	if name == "" {
+		name = CodecJSON
+	}
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownCodec, name)
+	}
+	return c, nil
+}
+
+// Decode reads a value written by any codec, whatever the current one is.
+func Decode(data []byte, v any) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty value", ErrUnknownCodec)
+	}
+
+	switch data[0] {
+	case headerGzipJSON:
+		return codecs[CodecGzip].(*gzipCodec).decode(data[1:], v)
+	case headerZstdJSON:
+		return codecs[CodecZstd].(*zstdCodec).decode(data[1:], v)
+	case headerMsgpackV1:
+		return msgpackCodec{}.decode(data[1:], v)
+	case '{':
+		return json.Unmarshal(data, v)
+	default:
+		return fmt.Errorf("%w: header %#x", ErrUnknownCodec, data[0])
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return CodecJSON }
+
+func (jsonCodec) Encode(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type gzipCodec struct {
+	writers sync.Pool
+}
+
+func (*gzipCodec) Name() string { return CodecGzip }
+
+func (c *gzipCodec) Encode(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)/2))
+	buf.WriteByte(headerGzipJSON)
+
+	zw, ok := c.writers.Get().(*gzip.Writer)
+	if ok {
+		zw.Reset(buf)
+	} else {
+		zw = gzip.NewWriter(buf)
+	}
+	defer c.writers.Put(zw)
+
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *gzipCodec) decode(data []byte, v any) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, v)
+}
+
+// zstdCodec shares one encoder and one decoder: EncodeAll and DecodeAll are
+// safe for concurrent use.
+type zstdCodec struct {
+	enc *zstd.Encoder
+	dec *zstd.Decoder
+}
+
+func newZstdCodec() *zstdCodec {
+	// Neither constructor fails without options that can be invalid.
+	enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+	return &zstdCodec{enc: enc, dec: dec}
+}
+
+func (*zstdCodec) Name() string { return CodecZstd }
+
+func (c *zstdCodec) Encode(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.enc.EncodeAll(data, []byte{headerZstdJSON}), nil
+}
+
+func (c *zstdCodec) decode(data []byte, v any) error {
+	plain, err := c.dec.DecodeAll(data, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, v)
+}
+
+// msgpackCodec encodes structs as maps keyed by their json names, so fields
+// can be added and removed the same way as in JSON.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return CodecMsgpack }
+
+func (msgpackCodec) Encode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(headerMsgpackV1)
+
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) decode(data []byte, v any) error {
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+	dec.Reset(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	// msgpack decodes times in the local zone, orders keep them in UTC.
+	if o, ok := v.(*model.Order); ok {
+		o.DateCreated = o.DateCreated.UTC()
+		o.Payment.PaymentDT = o.Payment.PaymentDT.UTC()
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var codecNames = []string{CodecJSON, CodecGzip, CodecZstd, CodecMsgpack}
+
+// benchOrder is shaped like a real order: a few items and every field set.
+func benchOrder() model.Order {
+	created := time.Date(2025, 7, 1, 12, 30, 0, 0, time.UTC)
+	o := model.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: model.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: model.Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     model.CurrencyUSD,
+			Provider:     "wbpay",
+			Amount:       181700,
+			PaymentDT:    created,
+			Bank:         "alpha",
+			DeliveryCost: 150000,
+			GoodsTotal:   31700,
+		},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		ShardKey:        "9",
+		SMID:            99,
+		DateCreated:     created,
+		OOFShard:        "1",
+	}
+	for i := range 5 {
+		o.Items = append(o.Items, model.Item{
+			ChrtID:      9934930 + i,
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       45300,
+			RID:         fmt.Sprintf("ab4219087a764ae0btest%d", i),
+			Name:        "Mascaras",
+			Sale:        30,
+			Size:        "0",
+			TotalPrice:  31700,
+			NMID:        2389212,
+			Brand:       "Vivienne Sabo",
+			Status:      202,
+		})
+	}
+	return o
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	want := benchOrder()
+	for _, name := range codecNames {
+		t.Run(name, func(t *testing.T) {
+			codec, err := CodecByName(name)
+			require.NoError(t, err)
+
+			data, err := codec.Encode(want)
+			require.NoError(t, err)
+
+			var got model.Order
+			require.NoError(t, Decode(data, &got))
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestDecodeMixedEntries(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemory()
+
+	// Entries written before and during a rollout stay readable by every
+	// replica, whichever codec it writes with.
+	for _, name := range codecNames {
+		cfg := testRedisConfig()
+		cfg.Codec = name
+		require.NoError(t, NewOrders(backend, cfg).Set(ctx, testOrder(name)))
+	}
+
+	reader := NewOrders(backend, testRedisConfig())
+	for _, name := range codecNames {
+		order, err := reader.Get(ctx, name)
+		require.NoError(t, err, name)
+		assert.Equal(t, name, order.OrderUID)
+	}
+
+	legacy, err := json.Marshal(testOrder("legacy"))
+	require.NoError(t, err)
+	require.NoError(t, backend.Set(ctx, reader.Keys().Order("legacy"), legacy, 0))
+	_, err = reader.Get(ctx, "legacy")
+	assert.NoError(t, err)
+
+	require.NoError(t, backend.Set(ctx, reader.Keys().Order("bad"), []byte{0xff, 1, 2}, 0))
+	_, err = reader.Get(ctx, "bad")
+	assert.ErrorIs(t, err, ErrUnknownCodec)
+
+	_, err = CodecByName("xml")
+	assert.ErrorIs(t, err, ErrUnknownCodec)
+}
+
+func BenchmarkCodecEncode(b *testing.B) {
+	order := benchOrder()
+	for _, name := range codecNames {
+		codec, _ := CodecByName(name)
+		b.Run(name, func(b *testing.B) {
+			data, _ := codec.Encode(order)
+			b.ReportMetric(float64(len(data)), "bytes/entry")
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := codec.Encode(order); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	order := benchOrder()
+	for _, name := range codecNames {
+		codec, _ := CodecByName(name)
+		b.Run(name, func(b *testing.B) {
+			data, err := codec.Encode(order)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(len(data)), "bytes/entry")
+			b.ReportAllocs()
+			for range b.N {
+				var o model.Order
+				if err := Decode(data, &o); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"l0/internal/domain"
+	"l0/internal/model"
+	"log"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// Indexed order fields. Every index is a sorted set of order ids scored by
+// date_created, so each answers "latest orders with this value" and time
+// range queries.
+const (
+	IndexCustomer        = "customer_id"
+	IndexTrackNumber     = "track_number"
+	IndexDeliveryService = "delivery_service"
+)
+
+var (
+	ErrNoIndex      = fmt.Errorf("cache: secondary indexes: %w", domain.ErrUnavailable)
+	ErrUnknownIndex = fmt.Errorf("cache: unknown index: %w", domain.ErrInvalid)
+)
+
+// IndexQuery selects order ids from a secondary index, or from all orders
+// when Field is empty. Orders created in [From, To) are returned newest
+// first, or oldest first with Oldest; zero bounds are open.
+type IndexQuery struct {
+	Field  string
+	Value  string
+	From   time.Time
+	To     time.Time
+	Oldest bool
+	Offset int
+	Limit  int
+}
+
+func indexScore(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func (o *Orders) indexer() (Indexer, bool) {
+	if !o.indexes {
+		return nil, false
+	}
+	ix, ok := o.cache.(Indexer)
+	return ix, ok
+}
+
+// indexKeys are the indexes an order belongs to. Empty fields are not
+// indexed.
+func (o *Orders) indexKeys(order model.Order) []string {
+	keys := []string{o.keys.Index("", "")}
+	for _, f := range []struct{ field, value string }{
+		{IndexCustomer, order.CustomerID},
+		{IndexTrackNumber, order.TrackNumber},
+		{IndexDeliveryService, order.DeliveryService},
+	} {
+		if f.value != "" {
+			keys = append(keys, o.keys.Index(f.field, f.value))
+		}
+	}
+	return keys
+}
+
+// index moves written orders into the indexes of their current field values
+// and out of the ones they were in before. Warm writes only index orders
+// that are not indexed yet, like they only cache orders that are absent.
+// Orders created before the retention window are dropped from the indexes
+// instead.
+func (o *Orders) index(ctx context.Context, writes []orderWrite) error {
+	ix, ok := o.indexer()
+	if !ok {
+		return nil
+	}
+
+	now := o.now()
+	prune := indexScore(o.indexedSince(now))
+	if o.retention <= 0 {
+		prune = math.Inf(-1)
+	}
+
+	var updates, expired []IndexUpdate
+	for _, w := range writes {
+		id := w.order.OrderUID
+		u := IndexUpdate{Record: o.keys.Indexed(id), Member: id}
+
+		// The record lives as long as the order stays in the window.
+		if o.retention > 0 {
+			u.TTL = o.retention - now.Sub(w.order.DateCreated)
+			if u.TTL <= 0 {
+				if !w.ifAbsent {
+					expired = append(expired, u)
+				}
+				continue
+			}
+			u.KeyTTL = o.retention
+		}
+
+		u.Keys = o.indexKeys(w.order)
+		u.Score = indexScore(w.order.DateCreated)
+		u.IfAbsent = w.ifAbsent
+		u.Prune = prune
+		updates = append(updates, u)
+	}
+
+	if err := ix.Unindex(ctx, expired...); err != nil {
+		return err
+	}
+	return ix.Reindex(ctx, updates...)
+}
+
+func (o *Orders) unindex(ctx context.Context, ids []string) error {
+	ix, ok := o.indexer()
+	if !ok {
+		return nil
+	}
+
+	updates := make([]IndexUpdate, len(ids))
+	for i, id := range ids {
+		updates[i] = IndexUpdate{Record: o.keys.Indexed(id), Member: id}
+	}
+	return ix.Unindex(ctx, updates...)
+}
+
+// IndexedSince is the creation time of the oldest orders the indexes still
+// hold, or zero when they hold orders of any age.
+func (o *Orders) IndexedSince() time.Time {
+	return o.indexedSince(o.now())
+}
+
+func (o *Orders) indexedSince(now time.Time) time.Time {
+	if o.retention <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-o.retention)
+}
+
+// indexesComplete is the index state once a build has finished.
+const indexesComplete = "complete"
+
+// BeginIndexBuild records that a pass indexing every order has started and
+// returns its token for FinishIndexBuild.
+func (o *Orders) BeginIndexBuild(ctx context.Context) (string, error) {
+	if _, ok := o.indexer(); !ok {
+		return "", nil
+	}
+	token := strconv.FormatUint(rand.Uint64(), 36)
+	if err := o.cache.Set(ctx, o.keys.IndexState(), []byte(token), 0); err != nil {
+		return "", err
+	}
+	o.indexDirty.Store(false)
+	return token, nil
+}
+
+// FinishIndexBuild marks the indexes complete, unless a write has left an
+// order out of them since the build with this token began.
+func (o *Orders) FinishIndexBuild(ctx context.Context, token string) (bool, error) {
+	if _, ok := o.indexer(); !ok || token == "" || o.indexDirty.Load() {
+		return false, nil
+	}
+	state, err := o.cache.Get(ctx, o.keys.IndexState())
+	if errors.Is(err, ErrMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if string(state) != token {
+		return false, nil
+	}
+	return true, o.cache.Set(ctx, o.keys.IndexState(), []byte(indexesComplete), 0)
+}
+
+// IndexesComplete reports whether the indexes hold every order of the
+// retention window. Only then may a listing trust them to find everything:
+// writes are indexed as they happen, but orders the cache never saw are
+// only indexed by a build.
+func (o *Orders) IndexesComplete(ctx context.Context) bool {
+	if _, ok := o.indexer(); !ok {
+		return false
+	}
+	state, err := o.cache.Get(ctx, o.keys.IndexState())
+	return err == nil && string(state) == indexesComplete
+}
+
+// indexesIncomplete records that a write did not reach the indexes. When
+// the state cannot be dropped now, the next write retries.
+func (o *Orders) indexesIncomplete(ctx context.Context) {
+	if _, ok := o.indexer(); !ok {
+		return
+	}
+	o.indexDirty.Store(true)
+	if err := o.cache.Delete(ctx, o.keys.IndexState()); err != nil {
+		log.Printf("Failed to mark the indexes incomplete: %v", err)
+		return
+	}
+	o.indexDirty.Store(false)
+}
+
+// Find returns the ids of the orders matching q from the secondary indexes.
+// The indexes know every order written to the cache within the retention
+// window, including the ones whose cached value has since expired.
+func (o *Orders) Find(ctx context.Context, q IndexQuery) ([]string, error) {
+	ix, ok := o.indexer()
+	if !ok {
+		return nil, ErrNoIndex
+	}
+
+	switch q.Field {
+	case "", IndexCustomer, IndexTrackNumber, IndexDeliveryService:
+	default:
+		return nil, domain.Errorf(ErrUnknownIndex, "unknown index %q", q.Field)
+	}
+
+	// Members older than the window may not have been pruned yet.
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if from := latest(q.From, o.IndexedSince()); !from.IsZero() {
+		lo = indexScore(from)
+	}
+	if !q.To.IsZero() {
+		// Scores are whole milliseconds.
+		hi = indexScore(q.To) - 1
+	}
+
+	return ix.IndexRange(ctx, o.keys.Index(q.Field, q.Value), lo, hi, !q.Oldest, q.Offset, q.Limit)
+}
+
+// GetMany returns the cached orders among ids by id.
+func (o *Orders) GetMany(ctx context.Context, ids []string) (map[string]model.Order, error) {
+	values, err := o.cache.MGet(ctx, o.keys.Orders(ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make(map[string]model.Order, len(ids))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		var order model.Order
+		if err := Decode(v, &order); err != nil {
+			continue
+		}
+		orders[ids[i]] = order
+	}
+	return orders, nil
+}
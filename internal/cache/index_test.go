@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"l0/internal/config"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexes(t *testing.T) {
+	ctx := context.Background()
+	cfg := testRedisConfig()
+	cfg.Indexes = true
+	orders := NewOrders(NewMemory(), cfg)
+
+	created := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	order := func(id, customer, service string, hour int) model.Order {
+		o := testOrder(id)
+		o.CustomerID = customer
+		o.DeliveryService = service
+		o.TrackNumber = "T" + id
+		o.DateCreated = created.Add(time.Duration(hour) * time.Hour)
+		return o
+	}
+
+	require.NoError(t, orders.SetMany(ctx, []model.Order{
+		order("a", "alice", "meest", 0),
+		order("b", "bob", "meest", 1),
+		order("c", "alice", "dhl", 2),
+		order("d", "alice", "meest", 3),
+	}))
+
+	ids, err := orders.Find(ctx, IndexQuery{Field: IndexCustomer, Value: "alice", Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "c"}, ids, "latest first")
+
+	ids, err = orders.Find(ctx, IndexQuery{Field: IndexDeliveryService, Value: "meest", Oldest: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "d"}, ids)
+
+	ids, err = orders.Find(ctx, IndexQuery{From: created.Add(time.Hour), To: created.Add(3 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "b"}, ids, "time range is half-open")
+
+	ids, err = orders.Find(ctx, IndexQuery{Field: IndexTrackNumber, Value: "Ta"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, ids)
+
+	// A changed field moves the order to its new index.
+	require.NoError(t, orders.Set(ctx, order("d", "carol", "meest", 3)))
+	ids, err = orders.Find(ctx, IndexQuery{Field: IndexCustomer, Value: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a"}, ids)
+
+	// A warm write never overrides the index entries of a newer version.
+	require.NoError(t, orders.Warm(ctx, []model.Order{order("d", "alice", "meest", 3)}))
+	ids, err = orders.Find(ctx, IndexQuery{Field: IndexCustomer, Value: "carol"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d"}, ids)
+
+	// Invalidated orders still exist and keep their index entries; removed
+	// ones are gone from the database and leave the indexes.
+	require.NoError(t, orders.Delete(ctx, "d"))
+	require.NoError(t, orders.Remove(ctx, "a"))
+	ids, err = orders.Find(ctx, IndexQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "c", "b"}, ids)
+	require.NoError(t, orders.Remove(ctx, "d"))
+
+	found, err := orders.GetMany(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Contains(t, found, "b")
+
+	_, err = orders.Find(ctx, IndexQuery{Field: "email"})
+	assert.ErrorIs(t, err, ErrUnknownIndex)
+	_, err = NewOrders(NewMemory(), testRedisConfig()).Find(ctx, IndexQuery{})
+	assert.ErrorIs(t, err, ErrNoIndex)
+}
+
+func TestIndexBuild(t *testing.T) {
+	ctx := context.Background()
+	cfg := testRedisConfig()
+	cfg.Indexes = true
+	cfg.Write = config.CacheWrite{Policy: PolicyWriteBehind, QueueSize: 1, BatchSize: 10, FlushInterval: time.Hour}
+	orders := NewOrders(NewMemory(), cfg)
+
+	assert.False(t, orders.IndexesComplete(ctx), "nothing has been built")
+
+	build, err := orders.BeginIndexBuild(ctx)
+	require.NoError(t, err)
+	complete, err := orders.FinishIndexBuild(ctx, build)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	assert.True(t, orders.IndexesComplete(ctx))
+
+	// A write dropped during a build leaves an order out.
+	build, err = orders.BeginIndexBuild(ctx)
+	require.NoError(t, err)
+	require.NoError(t, orders.Set(ctx, testOrder("a")))
+	require.NoError(t, orders.Set(ctx, testOrder("b")))
+	complete, err = orders.FinishIndexBuild(ctx, build)
+	require.NoError(t, err)
+	assert.False(t, complete)
+	assert.False(t, orders.IndexesComplete(ctx))
+
+	// So does clearing the cache.
+	build, err = orders.BeginIndexBuild(ctx)
+	require.NoError(t, err)
+	require.NoError(t, orders.Clear(ctx, ""))
+	complete, err = orders.FinishIndexBuild(ctx, build)
+	require.NoError(t, err)
+	assert.False(t, complete)
+}
+
+func TestIndexRetention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	cfg := testRedisConfig()
+	cfg.Indexes = true
+	cfg.IndexRetention = 24 * time.Hour
+	orders := NewOrders(m, cfg)
+	orders.now = m.now
+
+	order := func(id string, age time.Duration) model.Order {
+		o := testOrder(id)
+		o.CustomerID = "alice"
+		o.DateCreated = now.Add(-age)
+		return o
+	}
+
+	require.NoError(t, orders.SetMany(ctx, []model.Order{
+		order("a", 20*time.Hour),
+		order("b", 2*time.Hour),
+		order("old", 48*time.Hour),
+	}))
+	assert.Equal(t, now.Add(-24*time.Hour), orders.IndexedSince())
+
+	ids, err := orders.Find(ctx, IndexQuery{Field: IndexCustomer, Value: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, ids, "orders older than the window are not indexed")
+
+	// The record of a lives as long as a stays in the window.
+	now = now.Add(5 * time.Hour)
+	_, ok := m.indexRecord(orders.keys.Indexed("a"))
+	assert.False(t, ok)
+	_, ok = m.indexRecord(orders.keys.Indexed("b"))
+	assert.True(t, ok)
+
+	ids, err = orders.Find(ctx, IndexQuery{Field: IndexCustomer, Value: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, ids, "members left the window before they are pruned")
+
+	require.NoError(t, orders.Set(ctx, order("c", 0)))
+	assert.NotContains(t, m.zsets[orders.keys.Index(IndexCustomer, "alice")].members, "a", "adds prune members left the window")
+
+	// An order rewritten after leaving the window leaves its indexes.
+	now = now.Add(20 * time.Hour)
+	require.NoError(t, orders.Set(ctx, order("b", 27*time.Hour)))
+	_, ok = m.indexRecord(orders.keys.Indexed("b"))
+	assert.False(t, ok)
+
+	// Indexes nobody adds to expire with the window.
+	now = now.Add(24 * time.Hour)
+	var keys []string
+	require.NoError(t, m.Scan(ctx, orders.keys.Prefix(KindIndex), func(k []string) error {
+		keys = append(keys, k...)
+		return nil
+	}))
+	assert.Empty(t, keys)
+}
+
+func TestMemoryReindex(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	update := func(keys ...string) IndexUpdate {
+		return IndexUpdate{Record: "rec", Member: "a", Score: 1, Keys: keys, Prune: math.Inf(-1)}
+	}
+	members := func(key string) []string {
+		ids, err := m.IndexRange(ctx, key, math.Inf(-1), math.Inf(1), false, 0, 0)
+		require.NoError(t, err)
+		return ids
+	}
+
+	require.NoError(t, m.Reindex(ctx, update("x", "y")))
+	require.NoError(t, m.Reindex(ctx, update("y", "z")))
+	assert.Empty(t, members("x"))
+	assert.Equal(t, []string{"a"}, members("y"))
+	assert.Equal(t, []string{"a"}, members("z"))
+
+	u := update("x")
+	u.IfAbsent = true
+	require.NoError(t, m.Reindex(ctx, u))
+	assert.Empty(t, members("x"), "the record exists")
+
+	require.NoError(t, m.Unindex(ctx, IndexUpdate{Record: "rec", Member: "a"}))
+	assert.Empty(t, members("y"))
+	assert.Empty(t, members("z"))
+	_, ok := m.indexRecord("rec")
+	assert.False(t, ok)
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+)
+
+const (
+	OpWrite  = "write"
+	OpDelete = "delete"
+	// OpFlush asks to drop every local copy. It is sent when the cache is
+	// cleared and also delivered locally after each (re)subscription, when
+	// events may have been missed.
+	OpFlush = "flush"
+)
+
+// Invalidation announces that the cached versions of some orders changed.
+type Invalidation struct {
+	Op  string   `json:"op"`
+	IDs []string `json:"ids,omitempty"`
+}
+
+// publish is best effort: the write it describes has already succeeded, and
+// replicas that miss the event still drop their copy when its TTL runs out.
+func (o *Orders) publish(ctx context.Context, op string, ids ...string) {
+	ps, ok := o.cache.(PubSub)
+	if !ok {
+		return
+	}
+
+	msg, err := json.Marshal(Invalidation{Op: op, IDs: ids})
+	if err != nil {
+		log.Printf("Failed to marshal invalidation: %v", err)
+		return
+	}
+	if err := ps.Publish(ctx, o.keys.Invalidations(), msg); err != nil {
+		log.Printf("Failed to publish invalidation of %v: %v", ids, err)
+	}
+}
+
+// Invalidations calls fn for every invalidation published by any process
+// sharing the backend, this one included, until ctx is done. It returns at
+// once when the backend is not shared.
+func (o *Orders) Invalidations(ctx context.Context, fn func(Invalidation)) error {
+	ps, ok := o.cache.(PubSub)
+	if !ok {
+		return nil
+	}
+
+	flush := func() { fn(Invalidation{Op: OpFlush}) }
+	return ps.Subscribe(ctx, o.keys.Invalidations(), flush, func(msg []byte) {
+		var inv Invalidation
+		if err := json.Unmarshal(msg, &inv); err != nil {
+			log.Printf("Failed to unmarshal invalidation: %v", err)
+			// Whatever it was about is unknown now.
+			flush()
+			return
+		}
+		fn(inv)
+	})
+}
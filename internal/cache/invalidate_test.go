@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"l0/internal/config"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvalidationsAcrossReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The consumer and an API replica share the backend; the replica keeps
+	// local copies.
+	shared := NewMemory()
+	consumer := NewOrders(shared, testRedisConfig())
+	api := NewOrders(shared, testRedisConfig())
+	local := NewLocal(config.LocalCache{Enabled: true, TTL: time.Hour}, func(model.Order) int { return 1 })
+
+	events := make(chan Invalidation, 10)
+	done := make(chan error)
+	go func() {
+		done <- api.Invalidations(ctx, func(inv Invalidation) {
+			if inv.Op == OpFlush {
+				local.Purge()
+			} else {
+				local.Delete(inv.IDs...)
+			}
+			events <- inv
+		})
+	}()
+
+	assert.Equal(t, Invalidation{Op: OpFlush}, <-events, "subscribing flushes what may have been missed")
+
+	local.Set("a", testOrder("a"))
+	local.Set("b", testOrder("b"))
+
+	require.NoError(t, consumer.Set(ctx, testOrder("a")))
+	assert.Equal(t, Invalidation{Op: OpWrite, IDs: []string{"a"}}, <-events)
+	_, ok := local.Get("a")
+	assert.False(t, ok)
+	_, ok = local.Get("b")
+	assert.True(t, ok)
+
+	require.NoError(t, consumer.Delete(ctx, "b"))
+	assert.Equal(t, Invalidation{Op: OpDelete, IDs: []string{"b"}}, <-events)
+	_, ok = local.Get("b")
+	assert.False(t, ok)
+
+	local.Set("c", testOrder("c"))
+	require.NoError(t, consumer.Clear(ctx, ""))
+	assert.Equal(t, Invalidation{Op: OpFlush}, <-events)
+	assert.Zero(t, local.Stats().Entries)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+// asyncMemory delivers published messages later and on another goroutine,
+// as Redis does.
+type asyncMemory struct {
+	*Memory
+	pending sync.WaitGroup
+}
+
+func (m *asyncMemory) Publish(ctx context.Context, channel string, msg []byte) error {
+	m.pending.Add(1)
+	go func() {
+		defer m.pending.Done()
+		time.Sleep(5 * time.Millisecond)
+		_ = m.Memory.Publish(ctx, channel, msg)
+	}()
+	return nil
+}
+
+func TestFillKeepsLocalCopies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shared := &asyncMemory{Memory: NewMemory()}
+	consumer := NewOrders(shared, testRedisConfig())
+	api := NewOrders(shared, testRedisConfig())
+	local := NewLocal(config.LocalCache{Enabled: true, TTL: time.Hour}, func(model.Order) int { return 1 })
+
+	subscribed := make(chan struct{})
+	go api.Invalidations(ctx, func(inv Invalidation) {
+		if inv.Op == OpFlush {
+			local.Purge()
+			select {
+			case <-subscribed:
+			default:
+				close(subscribed)
+			}
+			return
+		}
+		local.Delete(inv.IDs...)
+	})
+	<-subscribed
+
+	// A miss loads the order, caches it and keeps it locally, as
+	// OrderService.GetOrderById does.
+	order, _, err := NewCoalescer(api).Order("a", func() (model.Order, error) { return testOrder("a"), nil })
+	require.NoError(t, err)
+	local.Set("a", order)
+	require.NoError(t, api.Warm(ctx, []model.Order{testOrder("b")}))
+	local.Set("b", testOrder("b"))
+
+	shared.pending.Wait()
+	_, ok := local.Get("a")
+	assert.True(t, ok, "filling the cache must not evict the copy it was filled with")
+	_, ok = local.Get("b")
+	assert.True(t, ok)
+
+	// A real change still reaches the replica.
+	require.NoError(t, consumer.Set(ctx, testOrder("a")))
+	shared.pending.Wait()
+	_, ok = local.Get("a")
+	assert.False(t, ok)
+}
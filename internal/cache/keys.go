@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"strings"
+)
+
+const (
+	KindOrder  = "order"
+	KindWarmup = "warmup"
+	// KindReconcile is the checkpoint of the last reconciliation pass.
+	KindReconcile = "reconcile"
+	// KindIndex is a hash tag: every index and index record lives in one
+	// cluster slot, so that an order moves between indexes in one script.
+	KindIndex = "{idx}"
+)
+
+// Keys builds every Redis key the service owns. All of them live under
+// <prefix>:<version>: so that clearing and syncing never touch foreign keys
+// and a new value format can be rolled out under a new version.
+type Keys struct {
+	namespace string
+}
+
+func NewKeys(prefix, version string) Keys {
+	return Keys{namespace: prefix + ":" + version + ":"}
+}
+
+// Namespace is the common prefix of all keys, including the trailing colon.
+func (k Keys) Namespace() string {
+	return k.namespace
+}
+
+func (k Keys) Order(id string) string {
+	return k.namespace + KindOrder + ":" + id
+}
+
+func (k Keys) Orders(ids ...string) []string {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = k.Order(id)
+	}
+	return keys
+}
+
+// Lock is the key of the short-lived lock guarding the loading of a value
+// of the given kind.
+func (k Keys) Lock(kind, id string) string {
+	return k.namespace + "lock:" + kind + ":" + id
+}
+
+// Missing marks an id the database does not know.
+func (k Keys) Missing(kind, id string) string {
+	return k.namespace + "missing:" + kind + ":" + id
+}
+
+// Checkpoint is where a long-running job of the given kind keeps its
+// position.
+func (k Keys) Checkpoint(kind string) string {
+	return k.namespace + "checkpoint:" + kind
+}
+
+// Index is the sorted set of the orders whose field has the given value.
+// Without a field it is the set of all orders.
+func (k Keys) Index(field, value string) string {
+	if field == "" {
+		return k.namespace + KindIndex + ":all"
+	}
+	return k.namespace + KindIndex + ":" + field + ":" + value
+}
+
+// Indexed lists the index keys an order is currently in, so that they can
+// be updated when the order changes.
+func (k Keys) Indexed(id string) string {
+	return k.namespace + KindIndex + ":indexed:" + id
+}
+
+// IndexState tells whether the indexes hold every order, see
+// Orders.IndexesComplete.
+func (k Keys) IndexState() string {
+	return k.namespace + KindIndex + ":state"
+}
+
+// Invalidations is the pub/sub channel that announces changed orders to the
+// replicas holding local copies.
+func (k Keys) Invalidations() string {
+	return k.namespace + "invalidate"
+}
+
+// Prefix is the common prefix of every key of the given kind, or of the
+// whole namespace when kind is empty.
+func (k Keys) Prefix(kind string) string {
+	if kind == "" {
+		return k.namespace
+	}
+	return k.namespace + kind + ":"
+}
+
+// OrderID extracts the order id from a key built by Order.
+func (k Keys) OrderID(key string) (string, bool) {
+	id, ok := strings.CutPrefix(key, k.namespace+KindOrder+":")
+	return id, ok && id != ""
+}
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeys(t *testing.T) {
+	keys := NewKeys("l0", "v1")
+
+	assert.Equal(t, "l0:v1:order:b563feb7b2b84best", keys.Order("b563feb7b2b84best"))
+	assert.Equal(t, []string{"l0:v1:order:a", "l0:v1:order:b"}, keys.Orders("a", "b"))
+	assert.Equal(t, "l0:v1:order:", keys.Prefix(KindOrder))
+	assert.Equal(t, "l0:v1:", keys.Prefix(""))
+	assert.Equal(t, "l0:v1:lock:order:a", keys.Lock(KindOrder, "a"))
+	assert.Equal(t, "l0:v1:missing:order:a", keys.Missing(KindOrder, "a"))
+	assert.Equal(t, "l0:v1:checkpoint:warmup", keys.Checkpoint(KindWarmup))
+
+	id, ok := keys.OrderID("l0:v1:order:b563feb7b2b84best")
+	assert.True(t, ok)
+	assert.Equal(t, "b563feb7b2b84best", id)
+
+	_, ok = keys.OrderID("l0:v2:order:b563feb7b2b84best")
+	assert.False(t, ok)
+	_, ok = keys.OrderID("order:b563feb7b2b84best")
+	assert.False(t, ok)
+}
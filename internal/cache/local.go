@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"container/list"
+	"l0/internal/config"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Local is a size-bounded in-process LRU cache that sits in front of Redis.
+// Entries expire after a TTL that is meant to be much shorter than the Redis
+// one: invalidations from other processes arrive over pub/sub and may be
+// lost, so the TTL bounds how long a stale copy can live.
+type Local[V any] struct {
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	lru        *list.List
+	bytes      int64
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+	sizeOf     func(V) int
+	now        func() time.Time
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+type localEntry[V any] struct {
+	key       string
+	value     V
+	size      int64
+	expiresAt time.Time
+}
+
+// Stats is what the API reports about its caches.
+type Stats struct {
+	Local        LocalStats `json:"local"`
+	NegativeHits int64      `json:"negative_hits"`
+	Write        WriteStats `json:"write"`
+}
+
+type LocalStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// NewLocal returns nil when the layer is disabled; a nil *Local is a valid
+// cache that never hits.
+func NewLocal[V any](cfg config.LocalCache, sizeOf func(V) int) *Local[V] {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	return &Local[V]{
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: cfg.MaxEntries,
+		maxBytes:   cfg.MaxBytes,
+		ttl:        cfg.TTL,
+		sizeOf:     sizeOf,
+		now:        time.Now,
+	}
+}
+
+func (c *Local[V]) Get(key string) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return zero, false
+	}
+
+	entry := el.Value.(*localEntry[V])
+	if c.ttl > 0 && !c.now().Before(entry.expiresAt) {
+		c.remove(el)
+		c.misses.Add(1)
+		return zero, false
+	}
+
+	c.lru.MoveToFront(el)
+	c.hits.Add(1)
+	return entry.value, true
+}
+
+func (c *Local[V]) Set(key string, value V) {
+	if c == nil {
+		return
+	}
+
+	size := int64(len(key))
+	if c.sizeOf != nil {
+		size += int64(c.sizeOf(value))
+	}
+	// A value that alone exceeds the budget would evict everything else.
+	if c.maxBytes > 0 && size > c.maxBytes {
+		c.Delete(key)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	entry := &localEntry[V]{key: key, value: value, size: size, expiresAt: c.now().Add(c.ttl)}
+	c.items[key] = c.lru.PushFront(entry)
+	c.bytes += size
+
+	for c.overflows() {
+		c.remove(c.lru.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *Local[V]) Delete(keys ...string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// Purge drops every entry.
+func (c *Local[V]) Purge() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+func (c *Local[V]) Stats() LocalStats {
+	if c == nil {
+		return LocalStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return LocalStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Entries:   c.lru.Len(),
+		Bytes:     c.bytes,
+	}
+}
+
+func (c *Local[V]) overflows() bool {
+	if c.lru.Len() == 0 {
+		return false
+	}
+	return (c.maxEntries > 0 && c.lru.Len() > c.maxEntries) ||
+		(c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+func (c *Local[V]) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*localEntry[V])
+	delete(c.items, entry.key)
+	c.bytes -= entry.size
+}
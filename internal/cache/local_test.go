@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"l0/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLocal(config.LocalCache{Enabled: true, MaxEntries: 2, TTL: time.Minute}, func(string) int { return 0 })
+
+	c.Set("a", "1")
+	c.Set("b", "2")
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	c.Set("c", "3")
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", v)
+
+	assert.Equal(t, LocalStats{Hits: 2, Misses: 1, Evictions: 1, Entries: 2, Bytes: 2}, c.Stats())
+}
+
+func TestLocalMaxBytesAndTTL(t *testing.T) {
+	now := time.Now()
+	c := NewLocal(config.LocalCache{Enabled: true, MaxBytes: 10, TTL: time.Second}, func(v string) int { return len(v) })
+	c.now = func() time.Time { return now }
+
+	c.Set("a", "12345")
+	c.Set("b", "12345")
+	assert.Equal(t, int64(1), c.Stats().Evictions)
+	assert.Equal(t, int64(6), c.Stats().Bytes)
+
+	c.Set("big", "12345678901")
+	_, ok := c.Get("big")
+	assert.False(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	assert.Zero(t, c.Stats().Entries)
+}
+
+func TestLocalDisabled(t *testing.T) {
+	c := NewLocal[string](config.LocalCache{}, nil)
+	assert.Nil(t, c)
+
+	c.Set("a", "1")
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Zero(t, c.Stats())
+}
@@ -0,0 +1,351 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is an unbounded in-process backend for tests and local runs.
+// Expired keys are dropped when they are next touched.
+type Memory struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+	zsets map[string]memoryZSet
+	locks uint64
+	now   func() time.Time
+
+	subs    map[string]map[int]func([]byte)
+	nextSub int
+}
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryZSet struct {
+	members   map[string]float64
+	expiresAt time.Time
+}
+
+var (
+	_ Cache     = (*Memory)(nil)
+	_ Refresher = (*Memory)(nil)
+	_ Locker    = (*Memory)(nil)
+	_ PubSub    = (*Memory)(nil)
+	_ Indexer   = (*Memory)(nil)
+)
+
+func NewMemory() *Memory {
+	return &Memory{
+		items: make(map[string]memoryItem),
+		zsets: make(map[string]memoryZSet),
+		subs:  make(map[string]map[int]func([]byte)),
+		now:   time.Now,
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.lookup(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return item.value, nil
+}
+
+func (m *Memory) GetEx(ctx context.Context, key string, ttl time.Duration) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.lookup(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	item.expiresAt = m.expiresAt(ttl)
+	m.items[key] = item
+	return item.value, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return m.MSet(ctx, Item{Key: key, Value: value, TTL: ttl})
+}
+
+func (m *Memory) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.items, key)
+		delete(m.zsets, key)
+	}
+	return nil
+}
+
+func (m *Memory) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		if item, ok := m.lookup(key); ok {
+			values[i] = item.value
+		}
+	}
+	return values, nil
+}
+
+func (m *Memory) MSet(ctx context.Context, items ...Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, item := range items {
+		if _, ok := m.lookup(item.Key); ok && item.IfAbsent {
+			continue
+		}
+		// Callers may reuse their buffers.
+		value := append([]byte(nil), item.Value...)
+		m.items[item.Key] = memoryItem{value: value, expiresAt: m.expiresAt(item.TTL)}
+	}
+	return nil
+}
+
+func (m *Memory) Scan(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	m.mu.Lock()
+	var keys []string
+	for key := range m.items {
+		if _, ok := m.lookup(key); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range m.zsets {
+		if _, ok := m.lookupZSet(key); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	m.mu.Unlock()
+
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	return fn(keys)
+}
+
+func (m *Memory) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.lookup(key); ok {
+		return nil, nil
+	}
+
+	m.locks++
+	token := strconv.FormatUint(m.locks, 10)
+	m.items[key] = memoryItem{value: []byte(token), expiresAt: m.expiresAt(ttl)}
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if cur, ok := m.lookup(key); ok && string(cur.value) == token {
+			delete(m.items, key)
+		}
+	}, nil
+}
+
+// Reindex holds the lock for the whole update, like the Redis script runs
+// without interleaving.
+func (m *Memory) Reindex(ctx context.Context, updates ...IndexUpdate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range updates {
+		prev, ok := m.indexRecord(u.Record)
+		if ok && u.IfAbsent {
+			continue
+		}
+		for _, key := range prev {
+			if !slices.Contains(u.Keys, key) {
+				m.zrem(key, u.Member)
+			}
+		}
+
+		for _, key := range u.Keys {
+			zs, ok := m.lookupZSet(key)
+			if !ok {
+				zs = memoryZSet{members: make(map[string]float64)}
+			}
+			zs.members[u.Member] = u.Score
+			for name, score := range zs.members {
+				if score < u.Prune {
+					delete(zs.members, name)
+				}
+			}
+			if u.KeyTTL > 0 {
+				zs.expiresAt = m.expiresAt(u.KeyTTL)
+			}
+			m.zsets[key] = zs
+		}
+
+		data, err := json.Marshal(u.Keys)
+		if err != nil {
+			return err
+		}
+		m.items[u.Record] = memoryItem{value: data, expiresAt: m.expiresAt(u.TTL)}
+	}
+	return nil
+}
+
+func (m *Memory) Unindex(ctx context.Context, updates ...IndexUpdate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range updates {
+		prev, _ := m.indexRecord(u.Record)
+		for _, key := range prev {
+			m.zrem(key, u.Member)
+		}
+		delete(m.items, u.Record)
+	}
+	return nil
+}
+
+// indexRecord reads the index keys listed in record. Records are only
+// written by Reindex, so a malformed one is treated as absent.
+func (m *Memory) indexRecord(record string) ([]string, bool) {
+	item, ok := m.lookup(record)
+	if !ok {
+		return nil, false
+	}
+	var keys []string
+	if err := json.Unmarshal(item.value, &keys); err != nil {
+		return nil, false
+	}
+	return keys, true
+}
+
+func (m *Memory) zrem(key, member string) {
+	zs, ok := m.lookupZSet(key)
+	if !ok {
+		return
+	}
+	delete(zs.members, member)
+	if len(zs.members) == 0 {
+		delete(m.zsets, key)
+	}
+}
+
+// IndexRange orders members with equal scores by name, as Redis does.
+func (m *Memory) IndexRange(ctx context.Context, key string, lo, hi float64, reverse bool, offset, limit int) ([]string, error) {
+	m.mu.Lock()
+	type member struct {
+		name  string
+		score float64
+	}
+	var members []member
+	zs, _ := m.lookupZSet(key)
+	for name, score := range zs.members {
+		if score >= lo && score <= hi {
+			members = append(members, member{name, score})
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if reverse {
+			a, b = b, a
+		}
+		if a.score != b.score {
+			return a.score < b.score
+		}
+		return a.name < b.name
+	})
+
+	members = members[min(offset, len(members)):]
+	if limit > 0 && len(members) > limit {
+		members = members[:limit]
+	}
+
+	names := make([]string, len(members))
+	for i, mb := range members {
+		names[i] = mb.name
+	}
+	return names, nil
+}
+
+// Publish delivers msg to the current subscribers synchronously.
+func (m *Memory) Publish(ctx context.Context, channel string, msg []byte) error {
+	m.mu.Lock()
+	fns := make([]func([]byte), 0, len(m.subs[channel]))
+	for _, fn := range m.subs[channel] {
+		fns = append(fns, fn)
+	}
+	m.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(append([]byte(nil), msg...))
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(ctx context.Context, channel string, onSubscribe func(), fn func(msg []byte)) error {
+	m.mu.Lock()
+	id := m.nextSub
+	m.nextSub++
+	if m.subs[channel] == nil {
+		m.subs[channel] = make(map[int]func([]byte))
+	}
+	m.subs[channel][id] = fn
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.subs[channel], id)
+		m.mu.Unlock()
+	}()
+
+	onSubscribe()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// lookup must be called with mu held.
+func (m *Memory) lookup(key string) (memoryItem, bool) {
+	item, ok := m.items[key]
+	if !ok {
+		return memoryItem{}, false
+	}
+	if !item.expiresAt.IsZero() && !m.now().Before(item.expiresAt) {
+		delete(m.items, key)
+		return memoryItem{}, false
+	}
+	return item, true
+}
+
+func (m *Memory) lookupZSet(key string) (memoryZSet, bool) {
+	zs, ok := m.zsets[key]
+	if !ok {
+		return memoryZSet{}, false
+	}
+	if !zs.expiresAt.IsZero() && !m.now().Before(zs.expiresAt) {
+		delete(m.zsets, key)
+		return memoryZSet{}, false
+	}
+	return zs, true
+}
+
+func (m *Memory) expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return m.now().Add(ttl)
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	require.NoError(t, m.Set(ctx, "a", []byte("1"), time.Second))
+	require.NoError(t, m.MSet(ctx, Item{Key: "b", Value: []byte("2")}, Item{Key: "other", Value: []byte("3")}))
+
+	v, err := m.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), v)
+
+	values, err := m.MGet(ctx, "a", "nope", "b")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("1"), nil, []byte("2")}, values)
+
+	var scanned []string
+	require.NoError(t, m.Scan(ctx, "", func(keys []string) error {
+		scanned = append(scanned, keys...)
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "b", "other"}, scanned)
+
+	now = now.Add(time.Second)
+	_, err = m.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	require.NoError(t, m.Delete(ctx, "b"))
+	_, err = m.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	unlock, err := m.TryLock(ctx, "lock", time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, unlock)
+	again, err := m.TryLock(ctx, "lock", time.Second)
+	require.NoError(t, err)
+	assert.Nil(t, again)
+	unlock()
+	again, err = m.TryLock(ctx, "lock", time.Second)
+	require.NoError(t, err)
+	assert.NotNil(t, again)
+}
+
+func TestOrders(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemory()
+	orders := NewOrders(backend, testRedisConfig())
+
+	require.NoError(t, backend.Set(ctx, "foreign", []byte("x"), 0))
+	require.NoError(t, orders.SetMissing(ctx, "a"))
+	missing, err := orders.IsMissing(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, missing)
+
+	// Ingesting the order drops its negative entry.
+	require.NoError(t, orders.Set(ctx, testOrder("a")))
+	missing, err = orders.IsMissing(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, missing)
+
+	got, err := orders.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", got.OrderUID)
+
+	require.NoError(t, orders.Clear(ctx, KindOrder))
+	_, err = orders.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	_, err = backend.Get(ctx, "foreign")
+	assert.NoError(t, err)
+
+	_, err = NewOrders(Noop{}, testRedisConfig()).Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const legacyOrderPrefix = "order:"
+
+type KeyMove struct {
+	From string
+	To   string
+}
+
+// MigrateKeys moves orders cached under the pre-namespace schemes into the
+// current namespace: `order:<id>` written by the sync job and the bare order
+// id written by the API and the consumer. A key is only moved when its value
+// is an order with the matching order_uid, so foreign keys stay untouched.
+// Keys that already have a counterpart in the namespace are dropped. The
+// old schemes predate cluster support, so cluster mode is refused: RENAMENX
+// cannot move a key to another slot.
+func (r *Redis) MigrateKeys(ctx context.Context, keys Keys, dryRun bool, report func(KeyMove)) (int, error) {
+	if _, ok := r.cluster(); ok {
+		return 0, errors.New("cache key migration is not supported on redis cluster")
+	}
+
+	moved := 0
+
+	iter := r.client.Scan(ctx, 0, "*", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasPrefix(key, keys.Namespace()) {
+			continue
+		}
+
+		id, err := r.legacyOrderID(ctx, key)
+		if err != nil {
+			return moved, fmt.Errorf("failed to inspect key %s: %w", key, err)
+		}
+		if id == "" {
+			continue
+		}
+
+		move := KeyMove{From: key, To: keys.Order(id)}
+		if report != nil {
+			report(move)
+		}
+		moved++
+
+		if dryRun {
+			continue
+		}
+
+		// RENAMENX keeps a value that is already in the namespace, which is
+		// at least as fresh as a leftover from the old scheme.
+		ok, err := r.client.RenameNX(ctx, move.From, move.To).Result()
+		if err != nil {
+			return moved, fmt.Errorf("failed to move %s to %s: %w", move.From, move.To, err)
+		}
+		if !ok {
+			if err := r.client.Del(ctx, move.From).Err(); err != nil {
+				return moved, fmt.Errorf("failed to delete %s: %w", move.From, err)
+			}
+		}
+	}
+
+	return moved, iter.Err()
+}
+
+func (r *Redis) legacyOrderID(ctx context.Context, key string) (string, error) {
+	id := strings.TrimPrefix(key, legacyOrderPrefix)
+
+	value, err := r.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) || strings.HasPrefix(err.Error(), "WRONGTYPE") {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var order struct {
+		OrderUID string `json:"order_uid"`
+	}
+	if json.Unmarshal(value, &order) != nil || order.OrderUID == "" || order.OrderUID != id {
+		return "", nil
+	}
+	return id, nil
+}
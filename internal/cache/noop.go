@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Noop caches nothing: every read misses and every write is dropped. It lets
+// the services run without any cache at all.
+type Noop struct{}
+
+var _ Cache = Noop{}
+
+func (Noop) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, ErrMiss
+}
+
+func (Noop) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (Noop) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+func (Noop) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	return make([][]byte, len(keys)), nil
+}
+
+func (Noop) MSet(ctx context.Context, items ...Item) error {
+	return nil
+}
+
+func (Noop) Scan(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	return nil
+}
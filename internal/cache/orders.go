@@ -0,0 +1,303 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"l0/internal/config"
+	"l0/internal/model"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Orders is the order cache the services work with. It owns the key layout,
+// the encoding, the TTL policy and negative entries, and keeps them in
+// whatever Cache backend it is given.
+type Orders struct {
+	cache    Cache
+	keys     Keys
+	codec    Codec
+	ttl      TTLPolicy
+	stampede config.Stampede
+	indexes  bool
+	// retention bounds the indexes, see config.Redis.IndexRetention.
+	retention time.Duration
+	now       func() time.Time
+
+	// indexDirty is set while the indexes are known to be incomplete but
+	// could not be marked so.
+	indexDirty atomic.Bool
+
+	policy        string
+	queue         *writeQueue
+	flushBatch    int
+	flushInterval time.Duration
+
+	negativeHits atomic.Int64
+}
+
+// NewOrders writes with the codec named by cfg.Codec. Open rejects unknown
+// names, so here they fall back to JSON.
+func NewOrders(c Cache, cfg config.Redis) *Orders {
+	codec, err := CodecByName(cfg.Codec)
+	if err != nil {
+		log.Printf("Falling back to JSON: %v", err)
+		codec = jsonCodec{}
+	}
+
+	o := &Orders{
+		cache:    c,
+		keys:     NewKeys(cfg.KeyPrefix, cfg.KeyVersion),
+		codec:    codec,
+		ttl:      NewTTLPolicy(cfg.TTL),
+		stampede: cfg.Stampede,
+		indexes:  cfg.Indexes,
+
+		retention: cfg.IndexRetention,
+		now:       time.Now,
+	}
+	o.initWritePolicy(cfg.Write)
+	return o
+}
+
+func (o *Orders) Keys() Keys {
+	return o.keys
+}
+
+func (o *Orders) Backend() Cache {
+	return o.cache
+}
+
+// Get returns ErrMiss when the order is not cached.
+func (o *Orders) Get(ctx context.Context, id string) (model.Order, error) {
+	var data []byte
+	var err error
+	// With sliding expiration every hit pushes the expiration further out.
+	if ttl, ok := o.ttl.OnRead(); ok {
+		if r, ok := o.cache.(Refresher); ok {
+			data, err = r.GetEx(ctx, o.keys.Order(id), ttl)
+		} else {
+			data, err = o.cache.Get(ctx, o.keys.Order(id))
+		}
+	} else {
+		data, err = o.cache.Get(ctx, o.keys.Order(id))
+	}
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	var order model.Order
+	if err := Decode(data, &order); err != nil {
+		log.Printf("Failed to unmarshal value: %v", err)
+		return model.Order{}, err
+	}
+	return order, nil
+}
+
+// Set caches an order with the expiration the TTL policy assigns to it and
+// drops the negative entry of its id, if any.
+func (o *Orders) Set(ctx context.Context, order model.Order) error {
+	return o.SetMany(ctx, []model.Order{order})
+}
+
+func (o *Orders) SetMany(ctx context.Context, orders []model.Order) error {
+	return o.setMany(ctx, orders, false, false)
+}
+
+// Warm caches orders read in bulk from the database. It never replaces a
+// cached order: that one was written by the consumer and may be newer than
+// the copy the bulk read saw.
+func (o *Orders) Warm(ctx context.Context, orders []model.Order) error {
+	return o.setMany(ctx, orders, true, false)
+}
+
+// Fill caches an order a reader has just loaded from the database. Unlike
+// Set it announces nothing to the replicas: the order did not change, and
+// the event would only evict the copy the reader keeps locally.
+func (o *Orders) Fill(ctx context.Context, order model.Order) error {
+	return o.setMany(ctx, []model.Order{order}, false, true)
+}
+
+// setMany applies the write policy: read-only processes drop the stale copy
+// of a changed order instead of writing it and skip warm and fill writes,
+// and write-behind ones queue writes for RunWriteBehind. Bulk warm writes are
+// already batched and would only overflow the queue, so they go straight
+// through. Only the value write is skipped or delayed: negative entries and
+// stale copies are dropped at once under every policy.
+func (o *Orders) setMany(ctx context.Context, orders []model.Order, ifAbsent, fill bool) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	switch {
+	case o.policy == PolicyReadOnly:
+		if ifAbsent || fill {
+			return nil
+		}
+		if err := o.clearMissing(ctx, orders); err != nil {
+			return err
+		}
+		ids := make([]string, len(orders))
+		for i, order := range orders {
+			ids[i] = order.OrderUID
+		}
+		// The orders are not reindexed under their new values, so they are
+		// taken out of the indexes until the next build puts them back.
+		o.indexesIncomplete(ctx)
+		return o.Remove(ctx, ids...)
+	case o.queue != nil && !ifAbsent:
+		// The orders exist from now on, not from the flush: negative
+		// entries must not answer for them while the write is queued.
+		if err := o.clearMissing(ctx, orders); err != nil {
+			return err
+		}
+		// A dropped change must not leave the previous version cached or
+		// indexed. A dropped fill has nothing cached to replace.
+		if dropped := o.queue.push(orders, fill, o.flushBatch); len(dropped) > 0 && !fill {
+			o.indexesIncomplete(ctx)
+			return o.Remove(ctx, dropped...)
+		}
+		return nil
+	}
+
+	writes := make([]orderWrite, len(orders))
+	for i, order := range orders {
+		writes[i] = orderWrite{order: order, ifAbsent: ifAbsent, fill: fill}
+	}
+	return o.write(ctx, writes)
+}
+
+func (o *Orders) write(ctx context.Context, writes []orderWrite) (err error) {
+	if o.indexDirty.Load() {
+		o.indexesIncomplete(ctx)
+	}
+
+	items := make([]Item, 0, len(writes))
+	orders := make([]model.Order, 0, len(writes))
+	changed := make([]string, 0, len(writes))
+	for _, w := range writes {
+		data, err := o.codec.Encode(w.order)
+		if err != nil {
+			log.Printf("Failed to encode value: %v", err)
+			return err
+		}
+		items = append(items, Item{Key: o.keys.Order(w.order.OrderUID), Value: data, TTL: o.ttl.ForOrder(w.order), IfAbsent: w.ifAbsent})
+		orders = append(orders, w.order)
+		// Warm and fill writes copy the database, so the replicas' local
+		// copies are still current.
+		if !w.ifAbsent && !w.fill {
+			changed = append(changed, w.order.OrderUID)
+		}
+	}
+
+	// A change that may not have been indexed leaves the indexes short of
+	// it.
+	defer func() {
+		if err != nil && len(changed) > 0 {
+			o.indexesIncomplete(ctx)
+		}
+	}()
+
+	if err := o.cache.MSet(ctx, items...); err != nil {
+		return err
+	}
+	if err := o.clearMissing(ctx, orders); err != nil {
+		return err
+	}
+	if err := o.index(ctx, writes); err != nil {
+		return fmt.Errorf("failed to index orders: %w", err)
+	}
+
+	if len(changed) > 0 {
+		o.publish(ctx, OpWrite, changed...)
+	}
+	return nil
+}
+
+// Delete invalidates the cached copies of orders that changed. The orders
+// stay in their indexes: readers load them from the database and the next
+// write moves them to the indexes of their new field values.
+func (o *Orders) Delete(ctx context.Context, ids ...string) error {
+	if o.queue != nil {
+		o.queue.remove(ids...)
+	}
+	if err := o.cache.Delete(ctx, o.keys.Orders(ids...)...); err != nil {
+		return err
+	}
+	o.publish(ctx, OpDelete, ids...)
+	return nil
+}
+
+// Remove drops orders the database no longer has, index entries included.
+func (o *Orders) Remove(ctx context.Context, ids ...string) error {
+	if err := o.Delete(ctx, ids...); err != nil {
+		return err
+	}
+	if err := o.unindex(ctx, ids); err != nil {
+		return fmt.Errorf("failed to unindex orders: %w", err)
+	}
+	return nil
+}
+
+// Clear deletes every key of the given kind (every kind when empty) inside
+// the namespace. Keys outside of it are never touched.
+func (o *Orders) Clear(ctx context.Context, kind string) error {
+	err := o.cache.Scan(ctx, o.keys.Prefix(kind), func(keys []string) error {
+		return o.cache.Delete(ctx, keys...)
+	})
+	if err != nil {
+		return err
+	}
+	if kind == "" || kind == KindOrder {
+		o.publish(ctx, OpFlush)
+	}
+	return nil
+}
+
+// clearMissing drops the negative entries of orders that exist now.
+func (o *Orders) clearMissing(ctx context.Context, orders []model.Order) error {
+	if o.ttl.Missing() <= 0 {
+		return nil
+	}
+
+	keys := make([]string, len(orders))
+	for i, order := range orders {
+		keys[i] = o.keys.Missing(KindOrder, order.OrderUID)
+	}
+	return o.cache.Delete(ctx, keys...)
+}
+
+// SetMissing remembers for a short while that the database has no order
+// with this id.
+func (o *Orders) SetMissing(ctx context.Context, id string) error {
+	ttl := o.ttl.Missing()
+	if ttl <= 0 || o.ReadOnly() {
+		return nil
+	}
+	return o.cache.Set(ctx, o.keys.Missing(KindOrder, id), []byte("1"), ttl)
+}
+
+// IsMissing reports whether id is negatively cached and counts the hit.
+func (o *Orders) IsMissing(ctx context.Context, id string) (bool, error) {
+	if o.ttl.Missing() <= 0 {
+		return false, nil
+	}
+
+	_, err := o.cache.Get(ctx, o.keys.Missing(KindOrder, id))
+	if errors.Is(err, ErrMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	o.negativeHits.Add(1)
+	return true, nil
+}
+
+// NegativeHits is the number of lookups answered by a negative entry since
+// start. A fast-growing value points at scrapers or clients stuck in a loop.
+func (o *Orders) NegativeHits() int64 {
+	return o.negativeHits.Load()
+}
@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"l0/internal/domain"
+	"l0/internal/model"
+	"l0/internal/repository"
+	"log/slog"
+	"time"
+)
+
+// reconcileOverlap is how far before the previous pass each pass starts
+// reading changes, so that writes which committed after that pass read past
+// them, or were stamped by a clock running behind, are still compared.
+const reconcileOverlap = time.Minute
+
+// ReconcileStats describes the drift one reconciliation pass found and
+// repaired. Checked counts the orders written since Since that were
+// compared, Scanned the cached orders that were looked up.
+type ReconcileStats struct {
+	Since     time.Time     `json:"since"`
+	Checked   int           `json:"checked"`
+	Scanned   int           `json:"scanned"`
+	Missing   int           `json:"missing"`
+	Stale     int           `json:"stale"`
+	Extra     int           `json:"extra"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+}
+
+func (s ReconcileStats) Drift() int {
+	return s.Missing + s.Stale + s.Extra
+}
+
+// Reconcile brings the cache in line with the database without clearing it.
+// It reads the orders written since the previous pass and compares each with
+// its cached encoding: differing ones are rewritten, and absent ones are
+// added if they were written within the lookback, since older ones are
+// expected to have expired. It then walks the cached orders, drops the ones
+// the database no longer has and rewrites the ones an older codec wrote.
+// Keys that match are not touched, so readers never see a window of misses.
+func (cs *CacheService) Reconcile(ctx context.Context) (ReconcileStats, error) {
+	stats := ReconcileStats{StartedAt: time.Now()}
+	stats.Since = cs.loadWatermark(ctx, stats.StartedAt)
+	recent := stats.StartedAt.Add(-cs.lookback)
+
+	cursor := repository.ChangeCursor{UpdatedAt: stats.Since.Add(-reconcileOverlap)}
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		page, err := cs.storage.GetOrdersChangedSince(ctx, cursor, cs.batchSize)
+		if err != nil {
+			return stats, fmt.Errorf("failed to read changed orders: %w", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		cursor = page[len(page)-1].Cursor()
+
+		if err := cs.reconcilePage(ctx, page, recent, &stats); err != nil {
+			return stats, err
+		}
+	}
+
+	err := cs.orders.cache.Scan(ctx, cs.orders.keys.Prefix(KindOrder), func(keys []string) error {
+		for len(keys) > 0 {
+			n := min(len(keys), cs.batchSize)
+			if err := cs.reconcileCached(ctx, keys[:n], &stats); err != nil {
+				return err
+			}
+			keys = keys[n:]
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to scan cached orders: %w", err)
+	}
+
+	cs.saveWatermark(ctx, stats.StartedAt)
+	stats.Duration = time.Since(stats.StartedAt)
+	return stats, nil
+}
+
+func (cs *CacheService) reconcilePage(ctx context.Context, page []repository.ChangedOrder, recent time.Time, stats *ReconcileStats) error {
+	ids := make([]string, len(page))
+	for i, c := range page {
+		ids[i] = c.Order.OrderUID
+	}
+
+	cached, err := cs.orders.cache.MGet(ctx, cs.orders.keys.Orders(ids...)...)
+	if err != nil {
+		return fmt.Errorf("failed to read cached orders: %w", err)
+	}
+
+	var missing []model.Order
+	var stale []string
+	for i, c := range page {
+		stats.Checked++
+
+		if cached[i] == nil {
+			if !c.UpdatedAt.Before(recent) {
+				missing = append(missing, c.Order)
+			}
+			continue
+		}
+
+		encoded, err := cs.orders.codec.Encode(c.Order)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(encoded, cached[i]) {
+			stale = append(stale, c.Order.OrderUID)
+		}
+	}
+
+	// Missing orders are written only if still absent, in case the consumer
+	// got there first.
+	if err := cs.orders.Warm(ctx, missing); err != nil {
+		return fmt.Errorf("failed to add missing orders: %w", err)
+	}
+	stats.Missing += len(missing)
+
+	return cs.rewrite(ctx, stale, stats)
+}
+
+// reconcileCached drops the cached orders the database no longer has and
+// rewrites the ones encoded by another codec, so a pass also finishes a
+// codec rollout.
+func (cs *CacheService) reconcileCached(ctx context.Context, keys []string, stats *ReconcileStats) error {
+	stats.Scanned += len(keys)
+
+	if err := cs.dropExtra(ctx, keys, stats); err != nil {
+		return err
+	}
+
+	cached, err := cs.orders.cache.MGet(ctx, keys...)
+	if err != nil {
+		return fmt.Errorf("failed to read cached orders: %w", err)
+	}
+
+	var foreign []string
+	for i, data := range cached {
+		if data == nil || encodedBy(cs.orders.codec, data) {
+			continue
+		}
+		if id, ok := cs.orders.keys.OrderID(keys[i]); ok {
+			foreign = append(foreign, id)
+		}
+	}
+
+	return cs.rewrite(ctx, foreign, stats)
+}
+
+// rewrite caches the stored version of each order. The orders are read
+// again right before they are written, since the consumer may have stored a
+// newer version after they were compared.
+func (cs *CacheService) rewrite(ctx context.Context, ids []string, stats *ReconcileStats) error {
+	for _, id := range ids {
+		order, err := cs.storage.GetOrderById(id)
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reload order %s: %w", id, err)
+		}
+		if err := cs.orders.Set(ctx, order); err != nil {
+			return fmt.Errorf("failed to rewrite order %s: %w", id, err)
+		}
+		stats.Stale++
+	}
+	return nil
+}
+
+// loadWatermark returns the start of the previous pass or, without one,
+// the start of the lookback.
+func (cs *CacheService) loadWatermark(ctx context.Context, now time.Time) time.Time {
+	data, err := cs.orders.cache.Get(ctx, cs.orders.keys.Checkpoint(KindReconcile))
+	if err != nil {
+		if !errors.Is(err, ErrMiss) {
+			cs.logger.Warn("failed to read reconcile watermark", slog.Any("error", err))
+		}
+		return now.Add(-cs.lookback)
+	}
+
+	var since time.Time
+	if err := since.UnmarshalText(data); err != nil {
+		cs.logger.Warn("ignoring malformed reconcile watermark", slog.Any("error", err))
+		return now.Add(-cs.lookback)
+	}
+	return since
+}
+
+// saveWatermark is best effort: losing it only narrows the next pass to the
+// lookback.
+func (cs *CacheService) saveWatermark(ctx context.Context, since time.Time) {
+	data, err := since.UTC().MarshalText()
+	if err == nil {
+		err = cs.orders.cache.Set(ctx, cs.orders.keys.Checkpoint(KindReconcile), data, 0)
+	}
+	if err != nil {
+		cs.logger.Warn("failed to save reconcile watermark", slog.Any("error", err))
+	}
+}
+
+func (cs *CacheService) dropExtra(ctx context.Context, keys []string, stats *ReconcileStats) error {
+	extra, err := cs.orphaned(ctx, keys)
+	if err != nil {
+		return err
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+
+	cs.logger.Info("dropping orders missing from the database", slog.Int("count", len(extra)))
+	if err := cs.orders.Remove(ctx, extra...); err != nil {
+		return err
+	}
+	stats.Extra += len(extra)
+	return nil
+}
+
+// orphaned returns the ids of the cached order keys whose orders the
+// database does not have.
+func (cs *CacheService) orphaned(ctx context.Context, keys []string) ([]string, error) {
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if id, ok := cs.orders.keys.OrderID(key); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	existing, err := cs.storage.ExistingOrderIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+
+	var extra []string
+	for _, id := range ids {
+		if !known[id] {
+			extra = append(extra, id)
+		}
+	}
+	return extra, nil
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"l0/internal/domain"
+	"l0/internal/model"
+	"l0/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reconcileStorage struct {
+	streamStorage
+	updated map[string]time.Time
+}
+
+func (s *reconcileStorage) GetOrderById(id string) (model.Order, error) {
+	for _, o := range s.orders {
+		if o.OrderUID == id {
+			return o, nil
+		}
+	}
+	return model.Order{}, domain.ErrOrderNotFound
+}
+
+func (s *reconcileStorage) ExistingOrderIDs(ctx context.Context, ids []string) ([]string, error) {
+	var existing []string
+	for _, id := range ids {
+		if _, err := s.GetOrderById(id); err == nil {
+			existing = append(existing, id)
+		}
+	}
+	return existing, nil
+}
+
+// GetOrdersChangedSince treats orders without a write time as written
+// before every cursor.
+func (s *reconcileStorage) GetOrdersChangedSince(ctx context.Context, after repository.ChangeCursor, limit int) ([]repository.ChangedOrder, error) {
+	var changed []repository.ChangedOrder
+	for _, o := range s.orders {
+		c := repository.ChangedOrder{Order: o, UpdatedAt: s.updated[o.OrderUID]}
+		if c.UpdatedAt.After(after.UpdatedAt) || c.UpdatedAt.Equal(after.UpdatedAt) && o.OrderUID > after.OrderUID {
+			changed = append(changed, c)
+		}
+	}
+	slices.SortFunc(changed, func(a, b repository.ChangedOrder) int {
+		if c := a.UpdatedAt.Compare(b.UpdatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Order.OrderUID, b.Order.OrderUID)
+	})
+	return changed[:min(limit, len(changed))], nil
+}
+
+func (s *reconcileStorage) write(o model.Order, at time.Time) {
+	if s.updated == nil {
+		s.updated = make(map[string]time.Time)
+	}
+	s.updated[o.OrderUID] = at
+	for i := range s.orders {
+		if s.orders[i].OrderUID == o.OrderUID {
+			s.orders[i] = o
+			return
+		}
+	}
+	s.orders = append(s.orders, o)
+}
+
+func TestReconcileRepairsDrift(t *testing.T) {
+	ctx := context.Background()
+	orders := NewOrders(NewMemory(), testRedisConfig())
+
+	storage := &reconcileStorage{}
+	recent := time.Now().Add(-30 * time.Minute)
+	for _, uid := range []string{"a", "b", "c"} {
+		storage.write(testOrder(uid), recent)
+	}
+	// "old" was written before the lookback: its key has expired.
+	storage.write(testOrder("old"), time.Now().Add(-2*time.Hour))
+
+	// "a" is up to date, "b" is stale, "c" is missing and "gone" was deleted
+	// from the database.
+	require.NoError(t, orders.Set(ctx, storage.orders[0]))
+	stale := testOrder("b")
+	stale.TrackNumber = "outdated"
+	require.NoError(t, orders.Set(ctx, stale))
+	require.NoError(t, orders.Set(ctx, testOrder("gone")))
+
+	cs := NewCacheService(orders, storage, WithBatchSize(2))
+	stats, err := cs.Reconcile(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, stats.Checked)
+	assert.Equal(t, 1, stats.Missing)
+	assert.Equal(t, 1, stats.Stale)
+	assert.Equal(t, 1, stats.Extra)
+
+	for _, o := range storage.orders[:3] {
+		cached, err := orders.Get(ctx, o.OrderUID)
+		require.NoError(t, err, o.OrderUID)
+		assert.Equal(t, o, cached)
+	}
+	_, err = orders.Get(ctx, "old")
+	assert.ErrorIs(t, err, ErrMiss)
+	_, err = orders.Get(ctx, "gone")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	// The next pass only compares what was written since the previous one.
+	stats, err = cs.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Checked)
+	assert.Equal(t, 3, stats.Scanned)
+	assert.Zero(t, stats.Drift())
+
+	// A write whose cache update was lost is found by the pass after it.
+	changed := testOrder("a")
+	changed.TrackNumber = "changed"
+	storage.write(changed, time.Now())
+
+	stats, err = cs.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Checked)
+	assert.Equal(t, 1, stats.Stale)
+
+	cached, err := orders.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, changed, cached)
+}
+
+func TestReconcileFinishesCodecRollout(t *testing.T) {
+	ctx := context.Background()
+	cfg := testRedisConfig()
+	backend := NewMemory()
+
+	storage := &reconcileStorage{}
+	storage.write(testOrder("a"), time.Now().Add(-2*time.Hour))
+	require.NoError(t, NewOrders(backend, cfg).Set(ctx, storage.orders[0]))
+
+	cfg.Codec = CodecMsgpack
+	orders := NewOrders(backend, cfg)
+	stats, err := NewCacheService(orders, storage).Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Checked)
+	assert.Equal(t, 1, stats.Stale)
+
+	data, err := backend.Get(ctx, orders.keys.Order("a"))
+	require.NoError(t, err)
+	assert.Equal(t, headerMsgpackV1, data[0])
+}
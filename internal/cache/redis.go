@@ -1,53 +1,534 @@
 package cache
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"l0/internal/config"
+	"l0/internal/domain"
 	"log"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+const scanCount = 1000
+
+const (
+	// subscribePing is how long a quiet subscription waits before checking
+	// that the connection is still alive.
+	subscribePing = 30 * time.Second
+
+	minResubscribeDelay = 100 * time.Millisecond
+	maxResubscribeDelay = 5 * time.Second
+)
+
+// unlockScript deletes the lock only if it still holds our token, so a
+// holder that outlived its lock cannot release someone else's.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// reindexScript applies an IndexUpdate: KEYS[1] is the record, KEYS[2..]
+// the indexes; ARGV holds the member, its score, the IfAbsent flag, the
+// prune bound and the index and record TTLs in milliseconds. The record is
+// written last, so a failed update is retried from the same state.
+var reindexScript = redis.NewScript(`
+local prev = redis.call("GET", KEYS[1])
+if prev and ARGV[3] == "1" then
+	return 0
+end
+
+local keys = {}
+for i = 2, #KEYS do
+	keys[KEYS[i]] = true
+end
+if prev then
+	for _, key in ipairs(cjson.decode(prev)) do
+		if not keys[key] then
+			redis.call("ZREM", key, ARGV[1])
+		end
+	end
+end
+
+local list = {}
+for i = 2, #KEYS do
+	redis.call("ZADD", KEYS[i], ARGV[2], ARGV[1])
+	if ARGV[4] ~= "-inf" then
+		redis.call("ZREMRANGEBYSCORE", KEYS[i], "-inf", "(" .. ARGV[4])
+	end
+	if tonumber(ARGV[5]) > 0 then
+		redis.call("PEXPIRE", KEYS[i], ARGV[5])
+	end
+	list[#list + 1] = KEYS[i]
+end
+
+if tonumber(ARGV[6]) > 0 then
+	redis.call("SET", KEYS[1], cjson.encode(list), "PX", ARGV[6])
+else
+	redis.call("SET", KEYS[1], cjson.encode(list))
+end
+return 1`)
+
+// unindexScript removes ARGV[1] from the indexes listed in the record
+// KEYS[1] and deletes the record.
+var unindexScript = redis.NewScript(`
+local prev = redis.call("GET", KEYS[1])
+if prev then
+	for _, key in ipairs(cjson.decode(prev)) do
+		redis.call("ZREM", key, ARGV[1])
+	end
+end
+return redis.call("DEL", KEYS[1])`)
+
+const (
+	ModeSingle   = "single"
+	ModeSentinel = "sentinel"
+	ModeCluster  = "cluster"
+)
+
+const defaultDialTimeout = 5 * time.Second
+
 type Redis struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
-func New(cfg config.Redis) *Redis {
+var (
+	_ Cache     = (*Redis)(nil)
+	_ Refresher = (*Redis)(nil)
+	_ Locker    = (*Redis)(nil)
+	_ PubSub    = (*Redis)(nil)
+	_ Indexer   = (*Redis)(nil)
+)
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr: cfg.Host + ":" + cfg.Port,
-		DB:   0,
-	})
+// New connects in the mode cfg asks for and pings the server, so a wrong
+// address, credential or certificate stops the service at startup instead
+// of failing every request later.
+func New(cfg config.Redis) (*Redis, error) {
+	opts, err := redisOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var client redis.UniversalClient
+	switch cfg.Mode {
+	case ModeSingle, "":
+		client = redis.NewClient(opts.Simple())
+	case ModeSentinel:
+		client = redis.NewFailoverClient(opts.Failover())
+	case ModeCluster:
+		client = redis.NewClusterClient(opts.Cluster())
+	}
 
-	return &Redis{client: rdb}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.DialTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis %s at %v: %w", cmp.Or(cfg.Mode, ModeSingle), opts.Addrs, err)
+	}
 
+	return &Redis{client: client}, nil
 }
 
-func (r *Redis) Set(key string, value interface{}) error {
-	jsonData, err := json.Marshal(value)
+func redisOptions(cfg config.Redis) (*redis.UniversalOptions, error) {
+	addrs := cfg.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{cfg.Host + ":" + cfg.Port}
+	}
+
+	switch cfg.Mode {
+	case ModeSingle, "":
+	case ModeSentinel:
+		if cfg.MasterName == "" {
+			return nil, errors.New("redis sentinel mode needs master_name")
+		}
+	case ModeCluster:
+		if cfg.DB != 0 {
+			return nil, errors.New("redis cluster supports only db 0")
+		}
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", cfg.Mode)
+	}
+
+	tlsConfig, err := redisTLS(cfg.TLS)
 	if err != nil {
-		log.Printf("Failed to marshal value: %v", err)
-		return err
+		return nil, err
 	}
 
-	err = r.client.Set(context.Background(), key, jsonData, 0).Err()
+	return &redis.UniversalOptions{
+		Addrs:            addrs,
+		DB:               cfg.DB,
+		Username:         cfg.User,
+		Password:         cfg.Password,
+		MasterName:       cfg.MasterName,
+		SentinelUsername: cfg.SentinelUser,
+		SentinelPassword: cfg.SentinelPassword,
+		TLSConfig:        tlsConfig,
+		DialTimeout:      cmp.Or(cfg.DialTimeout, defaultDialTimeout),
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+	}, nil
+}
+
+func redisTLS(cfg config.RedisTLS) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tc := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: cfg.ServerName}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in redis CA file %s", cfg.CAFile)
+		}
+		tc.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	return tc, nil
+}
+
+// cluster returns the client when it talks to Redis Cluster, where a
+// multi-key command fails unless all keys hash to the same slot.
+func (r *Redis) cluster() (*redis.ClusterClient, bool) {
+	c, ok := r.client.(*redis.ClusterClient)
+	return c, ok
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
 	if err != nil {
-		log.Printf("Failed to set key %s in Redis: %v", key, err)
+		log.Printf("Failed to get key %s from Redis: %v", key, err)
+		return nil, domain.Unavailable(err)
 	}
-	return err
+	return data, nil
 }
 
-func (r *Redis) Get(key string, dest interface{}) error {
-	jsonData, err := r.client.Get(context.Background(), key).Result()
+func (r *Redis) GetEx(ctx context.Context, key string, ttl time.Duration) ([]byte, error) {
+	data, err := r.client.GetEx(ctx, key, ttl).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
 	if err != nil {
 		log.Printf("Failed to get key %s from Redis: %v", key, err)
-		return err
+		return nil, domain.Unavailable(err)
+	}
+	return data, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := r.client.Set(ctx, key, value, ttl).Err()
+	if err != nil {
+		log.Printf("Failed to set key %s in Redis: %v", key, err)
+	}
+	return domain.Unavailable(err)
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var err error
+	if _, ok := r.cluster(); ok {
+		_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		})
+	} else {
+		err = r.client.Del(ctx, keys...).Err()
+	}
+	if err != nil {
+		log.Printf("Failed to delete keys %v from Redis: %v", keys, err)
+	}
+	return domain.Unavailable(err)
+}
+
+func (r *Redis) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	if _, ok := r.cluster(); ok {
+		return r.pipelinedGet(ctx, keys)
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, domain.Unavailable(err)
+	}
+
+	result := make([][]byte, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			result[i] = []byte(s)
+		}
+	}
+	return result, nil
+}
+
+// pipelinedGet is MGet for Redis Cluster: the pipeline is split by node.
+func (r *Redis) pipelinedGet(ctx context.Context, keys []string) ([][]byte, error) {
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, domain.Unavailable(err)
+	}
+
+	result := make([][]byte, len(keys))
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, domain.Unavailable(err)
+		}
+		result[i] = data
+	}
+	return result, nil
+}
+
+// MSet writes all items in one round trip. Unlike MSET it keeps a TTL per key.
+func (r *Redis) MSet(ctx context.Context, items ...Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, item := range items {
+			if item.IfAbsent {
+				pipe.SetNX(ctx, item.Key, item.Value, item.TTL)
+			} else {
+				pipe.Set(ctx, item.Key, item.Value, item.TTL)
+			}
+		}
+		return nil
+	})
+	return domain.Unavailable(err)
+}
+
+// Scan walks every master in cluster mode. fn is never called concurrently.
+func (r *Redis) Scan(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	pattern := escapeGlob(prefix) + "*"
+
+	c, ok := r.cluster()
+	if !ok {
+		return scanNode(ctx, r.client, pattern, fn)
 	}
 
-	err = json.Unmarshal([]byte(jsonData), dest)
+	var mu sync.Mutex
+	return c.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		return scanNode(ctx, node, pattern, func(keys []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return fn(keys)
+		})
+	})
+}
+
+func scanNode(ctx context.Context, node redis.Cmdable, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := node.Scan(ctx, cursor, pattern, scanCount).Result()
+		if err != nil {
+			return domain.Unavailable(err)
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (r *Redis) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := r.client.SetNX(ctx, key, token, ttl).Result()
 	if err != nil {
-		log.Printf("Failed to unmarshal value: %v", err)
+		return nil, domain.Unavailable(err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return func() {
+		unlockScript.Run(context.Background(), r.client, []string{key}, token)
+	}, nil
+}
+
+func (r *Redis) Publish(ctx context.Context, channel string, msg []byte) error {
+	return domain.Unavailable(r.client.Publish(ctx, channel, msg).Err())
+}
+
+// Subscribe keeps the subscription alive across connection losses: go-redis
+// reconnects and resubscribes on the next receive, and the confirmation that
+// follows is reported through onSubscribe.
+func (r *Redis) Subscribe(ctx context.Context, channel string, onSubscribe func(), fn func(msg []byte)) error {
+	sub := r.client.Subscribe(ctx, channel)
+	defer sub.Close()
+
+	delay := minResubscribeDelay
+	for {
+		msg, err := sub.ReceiveTimeout(ctx, subscribePing)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				// A failed ping marks the connection as broken, so the next
+				// receive reconnects.
+				if err := sub.Ping(ctx); err != nil {
+					log.Printf("Redis subscription to %s is down: %v", channel, err)
+				}
+				continue
+			}
+
+			log.Printf("Redis subscription to %s failed, retrying in %s: %v", channel, delay, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, maxResubscribeDelay)
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				delay = minResubscribeDelay
+				onSubscribe()
+			}
+		case *redis.Message:
+			fn([]byte(m.Payload))
+		}
+	}
+}
+
+// Reindex sends the scripts in one pipeline. EVALSHA cannot fall back to
+// EVAL inside a pipeline, so the script bodies are sent every time.
+func (r *Redis) Reindex(ctx context.Context, updates ...IndexUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, u := range updates {
+			ifAbsent := "0"
+			if u.IfAbsent {
+				ifAbsent = "1"
+			}
+			keys := append([]string{u.Record}, u.Keys...)
+			reindexScript.Eval(ctx, pipe, keys, u.Member, formatScore(u.Score), ifAbsent,
+				formatScore(u.Prune), u.KeyTTL.Milliseconds(), u.TTL.Milliseconds())
+		}
+		return nil
+	})
+	return domain.Unavailable(err)
+}
+
+func (r *Redis) Unindex(ctx context.Context, updates ...IndexUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, u := range updates {
+			unindexScript.Eval(ctx, pipe, []string{u.Record}, u.Member)
+		}
+		return nil
+	})
+	return domain.Unavailable(err)
+}
+
+func (r *Redis) IndexRange(ctx context.Context, key string, lo, hi float64, reverse bool, offset, limit int) ([]string, error) {
+	args := redis.ZRangeArgs{
+		Key:     key,
+		Start:   formatScore(lo),
+		Stop:    formatScore(hi),
+		ByScore: true,
+		Rev:     reverse,
+	}
+	if limit > 0 || offset > 0 {
+		args.Offset, args.Count = int64(offset), int64(limit)
+		if limit <= 0 {
+			args.Count = -1
+		}
+	}
+	ids, err := r.client.ZRangeArgs(ctx, args).Result()
+	if err != nil {
+		return nil, domain.Unavailable(err)
+	}
+	return ids, nil
+}
+
+func formatScore(f float64) string {
+	switch {
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsInf(f, 1):
+		return "+inf"
+	default:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+}
+
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
 	}
-	return err
+	return b.String()
 }
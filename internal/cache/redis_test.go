@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"l0/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisOptions(t *testing.T) {
+	opts, err := redisOptions(config.Redis{Host: "cache", Port: "6380", User: "app", Password: "secret", DB: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cache:6380"}, opts.Addrs)
+	assert.Equal(t, "app", opts.Username)
+	assert.Equal(t, 3, opts.DB)
+	assert.Equal(t, defaultDialTimeout, opts.DialTimeout)
+	assert.Nil(t, opts.TLSConfig)
+
+	opts, err = redisOptions(config.Redis{Mode: ModeCluster, Addrs: []string{"a:7000", "b:7000"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a:7000", "b:7000"}, opts.Addrs)
+
+	_, err = redisOptions(config.Redis{Mode: ModeCluster, DB: 1})
+	assert.Error(t, err)
+	_, err = redisOptions(config.Redis{Mode: ModeSentinel})
+	assert.Error(t, err)
+	_, err = redisOptions(config.Redis{Mode: "replicated"})
+	assert.Error(t, err)
+}
+
+func TestRedisTLS(t *testing.T) {
+	tc, err := redisTLS(config.RedisTLS{Enabled: true, ServerName: "cache.internal"})
+	require.NoError(t, err)
+	assert.Equal(t, "cache.internal", tc.ServerName)
+	assert.Nil(t, tc.RootCAs)
+
+	_, err = redisTLS(config.RedisTLS{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+
+	junk := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(junk, []byte("not a certificate"), 0o600))
+	_, err = redisTLS(config.RedisTLS{Enabled: true, CAFile: junk})
+	assert.Error(t, err)
+}
+
+func TestRedisFailsFast(t *testing.T) {
+	start := time.Now()
+	_, err := New(config.Redis{Host: "127.0.0.1", Port: "1", DialTimeout: 200 * time.Millisecond})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"l0/internal/config"
+	"l0/internal/model"
+	"math/rand/v2"
+	"time"
+)
+
+type TTLPolicy struct {
+	cfg config.CacheTTL
+	now func() time.Time
+}
+
+func NewTTLPolicy(cfg config.CacheTTL) TTLPolicy {
+	return TTLPolicy{cfg: cfg, now: time.Now}
+}
+
+// ForOrder is the expiration of a freshly written order. Zero means the key
+// never expires.
+func (p TTLPolicy) ForOrder(order model.Order) time.Duration {
+	ttl := p.cfg.Default
+	if p.cfg.Recent > 0 && p.cfg.RecentWindow > 0 && p.now().Sub(order.DateCreated) < p.cfg.RecentWindow {
+		ttl = p.cfg.Recent
+	}
+	return p.jitter(ttl)
+}
+
+// Default is the expiration of values that are not orders.
+func (p TTLPolicy) Default() time.Duration {
+	return p.jitter(p.cfg.Default)
+}
+
+// Missing is the expiration of a negative entry; zero disables them.
+func (p TTLPolicy) Missing() time.Duration {
+	return p.cfg.Missing
+}
+
+// OnRead reports whether a read should renew the key and for how long.
+func (p TTLPolicy) OnRead() (time.Duration, bool) {
+	if !p.cfg.Sliding {
+		return 0, false
+	}
+
+	ttl := p.cfg.Hot
+	if ttl <= 0 {
+		ttl = p.cfg.Default
+	}
+	if ttl <= 0 {
+		return 0, false
+	}
+	return p.jitter(ttl), true
+}
+
+// jitter shortens ttl by a random part of up to Jitter*ttl so that keys
+// written together do not expire together.
+func (p TTLPolicy) jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || p.cfg.Jitter <= 0 {
+		return ttl
+	}
+
+	spread := time.Duration(float64(ttl) * min(p.cfg.Jitter, 1))
+	if spread <= 0 {
+		return ttl
+	}
+	return ttl - rand.N(spread)
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"l0/internal/config"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLPolicy(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	policy := NewTTLPolicy(config.CacheTTL{
+		Default:      24 * time.Hour,
+		Recent:       72 * time.Hour,
+		RecentWindow: 7 * 24 * time.Hour,
+	})
+	policy.now = func() time.Time { return now }
+
+	assert.Equal(t, 72*time.Hour, policy.ForOrder(model.Order{DateCreated: now.Add(-time.Hour)}))
+	assert.Equal(t, 24*time.Hour, policy.ForOrder(model.Order{DateCreated: now.AddDate(0, -1, 0)}))
+
+	_, sliding := policy.OnRead()
+	assert.False(t, sliding)
+}
+
+func TestTTLPolicySlidingAndJitter(t *testing.T) {
+	policy := NewTTLPolicy(config.CacheTTL{Default: time.Hour, Sliding: true, Jitter: 0.1})
+
+	for range 100 {
+		ttl := policy.Default()
+		assert.LessOrEqual(t, ttl, time.Hour)
+		assert.Greater(t, ttl, 54*time.Minute)
+	}
+
+	ttl, sliding := policy.OnRead()
+	assert.True(t, sliding)
+	assert.Greater(t, ttl, 54*time.Minute)
+
+	assert.Zero(t, NewTTLPolicy(config.CacheTTL{Jitter: 0.5}).Default())
+}
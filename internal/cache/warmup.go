@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"l0/internal/repository"
+	"log/slog"
+	"time"
+)
+
+// checkpointTTL bounds how long an interrupted warmup can be resumed. After
+// that much time the cache has changed too much to trust the checkpoint.
+const checkpointTTL = 24 * time.Hour
+
+type WarmupProgress struct {
+	Cursor    repository.OrderCursor `json:"cursor"`
+	Warmed    int                    `json:"warmed"`
+	Total     int                    `json:"total"`
+	StartedAt time.Time              `json:"started_at"`
+	// IndexBuild is the token of the index build the warmup completes.
+	IndexBuild string `json:"index_build,omitempty"`
+}
+
+// Warmup streams every order into the cache, newest first, one page per
+// pipelined write. After each page the position is checkpointed in the cache
+// so that a restarted process continues where the previous one stopped.
+// Orders already in the cache are left alone, which makes it safe to run
+// while the consumer is writing. A warmup that gets through every order
+// marks the indexes complete, unless some write missed them meanwhile.
+func (cs *CacheService) Warmup(ctx context.Context) error {
+	progress, resumed := cs.loadCheckpoint(ctx)
+	if resumed {
+		cs.logger.Info("resuming cache warmup",
+			slog.Int("warmed", progress.Warmed),
+			slog.String("after", progress.Cursor.OrderUID),
+		)
+	} else {
+		cs.logger.Info("starting cache warmup")
+
+		build, err := cs.orders.BeginIndexBuild(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to start index build: %w", err)
+		}
+		progress.IndexBuild = build
+	}
+
+	total, err := cs.storage.CountOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count orders: %w", err)
+	}
+	progress.Total = total
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page, err := cs.storage.GetOrdersNewestFirst(ctx, progress.Cursor, cs.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read orders after %q: %w", progress.Cursor.OrderUID, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		if err := cs.orders.Warm(ctx, page); err != nil {
+			return fmt.Errorf("failed to cache orders: %w", err)
+		}
+
+		progress.Cursor = repository.CursorAt(page[len(page)-1])
+		progress.Warmed += len(page)
+		cs.saveCheckpoint(ctx, progress)
+
+		cs.logger.Info("cache warmup progress",
+			slog.Int("warmed", progress.Warmed),
+			slog.Int("total", progress.Total),
+			slog.Duration("elapsed", time.Since(progress.StartedAt)),
+		)
+	}
+
+	if err := cs.orders.cache.Delete(ctx, cs.orders.keys.Checkpoint(KindWarmup)); err != nil {
+		cs.logger.Warn("failed to delete warmup checkpoint", slog.Any("error", err))
+	}
+
+	complete, err := cs.orders.FinishIndexBuild(ctx, progress.IndexBuild)
+	if err != nil {
+		cs.logger.Warn("failed to mark the indexes complete", slog.Any("error", err))
+	} else if complete {
+		cs.logger.Info("cache indexes complete")
+	}
+
+	cs.logger.Info("cache warmup completed",
+		slog.Int("orders_cached", progress.Warmed),
+		slog.Duration("elapsed", time.Since(progress.StartedAt)),
+	)
+	return nil
+}
+
+func (cs *CacheService) loadCheckpoint(ctx context.Context) (WarmupProgress, bool) {
+	fresh := WarmupProgress{StartedAt: time.Now()}
+
+	data, err := cs.orders.cache.Get(ctx, cs.orders.keys.Checkpoint(KindWarmup))
+	if err != nil {
+		if !errors.Is(err, ErrMiss) {
+			cs.logger.Warn("failed to read warmup checkpoint", slog.Any("error", err))
+		}
+		return fresh, false
+	}
+
+	var progress WarmupProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		cs.logger.Warn("ignoring malformed warmup checkpoint", slog.Any("error", err))
+		return fresh, false
+	}
+	return progress, true
+}
+
+// saveCheckpoint is best effort: losing it only means warming again from
+// the newest order.
+func (cs *CacheService) saveCheckpoint(ctx context.Context, progress WarmupProgress) {
+	data, err := json.Marshal(progress)
+	if err == nil {
+		err = cs.orders.cache.Set(ctx, cs.orders.keys.Checkpoint(KindWarmup), data, checkpointTTL)
+	}
+	if err != nil {
+		cs.logger.Warn("failed to save warmup checkpoint", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"l0/internal/model"
+	"l0/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamStorage struct {
+	repository.Repository
+	orders  []model.Order
+	cursors []repository.OrderCursor
+	failAt  int
+}
+
+func (s *streamStorage) CountOrders(ctx context.Context) (int, error) {
+	return len(s.orders), nil
+}
+
+func (s *streamStorage) GetOrdersNewestFirst(ctx context.Context, after repository.OrderCursor, limit int) ([]model.Order, error) {
+	s.cursors = append(s.cursors, after)
+	if len(s.cursors) == s.failAt {
+		return nil, errors.New("connection reset")
+	}
+
+	start := 0
+	if !after.IsZero() {
+		for i, o := range s.orders {
+			if o.OrderUID == after.OrderUID {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+limit, len(s.orders))
+	return s.orders[start:end], nil
+}
+
+// SampleOrdersNewestFirst ignores the fraction: every order is sampled.
+func (s *streamStorage) SampleOrdersNewestFirst(ctx context.Context, after repository.OrderCursor, limit int, fraction float64) ([]model.Order, error) {
+	return s.GetOrdersNewestFirst(ctx, after, limit)
+}
+
+func TestWarmupResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	orders := NewOrders(NewMemory(), testRedisConfig())
+
+	storage := &streamStorage{failAt: 2}
+	created := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	for i, uid := range []string{"e", "d", "c", "b", "a"} {
+		o := testOrder(uid)
+		o.DateCreated = created.Add(-time.Duration(i) * time.Hour)
+		storage.orders = append(storage.orders, o)
+	}
+
+	// The consumer already cached a newer version of "d".
+	fresh := testOrder("d")
+	fresh.TrackNumber = "from consumer"
+	require.NoError(t, orders.Set(ctx, fresh))
+
+	cs := NewCacheService(orders, storage, WithBatchSize(2))
+	require.Error(t, cs.Warmup(ctx))
+
+	require.NoError(t, cs.Warmup(ctx))
+	assert.Equal(t, repository.CursorAt(storage.orders[1]), storage.cursors[2], "second run resumes after the checkpoint")
+
+	for _, o := range storage.orders {
+		_, err := orders.Get(ctx, o.OrderUID)
+		assert.NoError(t, err, o.OrderUID)
+	}
+
+	d, err := orders.Get(ctx, "d")
+	require.NoError(t, err)
+	assert.Equal(t, "from consumer", d.TrackNumber)
+
+	_, err = orders.cache.Get(ctx, orders.keys.Checkpoint(KindWarmup))
+	assert.ErrorIs(t, err, ErrMiss)
+}
@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"l0/internal/config"
+	"l0/internal/model"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	PolicyWriteThrough = "write-through"
+	PolicyWriteBehind  = "write-behind"
+	PolicyReadOnly     = "read-only"
+)
+
+// drainTimeout bounds the last flush of the write-behind queue on shutdown.
+const drainTimeout = 5 * time.Second
+
+type WriteStats struct {
+	Policy     string `json:"policy"`
+	QueueDepth int    `json:"queue_depth"`
+	QueueSize  int    `json:"queue_size"`
+	Coalesced  int64  `json:"coalesced"`
+	Dropped    int64  `json:"dropped"`
+	Flushed    int64  `json:"flushed"`
+	Failed     int64  `json:"failed"`
+}
+
+func checkPolicy(policy string) error {
+	switch policy {
+	case PolicyWriteThrough, PolicyWriteBehind, PolicyReadOnly, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown cache write policy %q", policy)
+	}
+}
+
+type orderWrite struct {
+	order    model.Order
+	ifAbsent bool
+	// fill marks a copy of the database that changes nothing, so it is not
+	// announced to the replicas.
+	fill bool
+}
+
+// writeQueue holds the orders waiting for a write-behind flush. Writes are
+// coalesced by order id, so a burst of updates to one order costs a single
+// Redis write. When the queue is full, writes of orders not yet queued are
+// dropped and push returns their ids: the caller must invalidate them, or a
+// previously cached version would keep being served.
+type writeQueue struct {
+	mu      sync.Mutex
+	pending map[string]orderWrite
+	fifo    []string
+	size    int
+	full    chan struct{}
+
+	coalesced atomic.Int64
+	dropped   atomic.Int64
+	flushed   atomic.Int64
+	failed    atomic.Int64
+}
+
+func newWriteQueue(size int) *writeQueue {
+	return &writeQueue{
+		pending: make(map[string]orderWrite),
+		size:    size,
+		full:    make(chan struct{}, 1),
+	}
+}
+
+func (q *writeQueue) push(orders []model.Order, fill bool, batch int) (dropped []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, order := range orders {
+		id := order.OrderUID
+		if prev, ok := q.pending[id]; ok {
+			// A change stays announced when a fill replaces it.
+			q.pending[id] = orderWrite{order: order, fill: prev.fill && fill}
+			q.coalesced.Add(1)
+			continue
+		}
+		if len(q.pending) >= q.size {
+			q.dropped.Add(1)
+			dropped = append(dropped, id)
+			continue
+		}
+		q.pending[id] = orderWrite{order: order, fill: fill}
+		q.fifo = append(q.fifo, id)
+	}
+
+	if len(q.pending) >= batch {
+		select {
+		case q.full <- struct{}{}:
+		default:
+		}
+	}
+	return dropped
+}
+
+// take removes up to n of the oldest queued writes.
+func (q *writeQueue) take(n int) []orderWrite {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	writes := make([]orderWrite, 0, min(n, len(q.pending)))
+	for len(writes) < n && len(q.fifo) > 0 {
+		id := q.fifo[0]
+		q.fifo = q.fifo[1:]
+		// Ids removed by a delete stay in fifo until they come up here.
+		if w, ok := q.pending[id]; ok {
+			delete(q.pending, id)
+			writes = append(writes, w)
+		}
+	}
+	return writes
+}
+
+// remove forgets queued writes so that a flush cannot bring back an order
+// that was just deleted.
+func (q *writeQueue) remove(ids ...string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, id := range ids {
+		delete(q.pending, id)
+	}
+}
+
+func (q *writeQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+func (o *Orders) initWritePolicy(cfg config.CacheWrite) {
+	o.policy = cfg.Policy
+	if err := checkPolicy(o.policy); err != nil || o.policy == "" {
+		o.policy = PolicyWriteThrough
+	}
+
+	o.flushBatch = max(cfg.BatchSize, 1)
+	o.flushInterval = cfg.FlushInterval
+	if o.flushInterval <= 0 {
+		o.flushInterval = 100 * time.Millisecond
+	}
+	if o.policy == PolicyWriteBehind {
+		o.queue = newWriteQueue(max(cfg.QueueSize, 1))
+	}
+}
+
+// ReadOnly reports whether this process must not write orders to the cache.
+func (o *Orders) ReadOnly() bool {
+	return o.policy == PolicyReadOnly
+}
+
+func (o *Orders) WriteStats() WriteStats {
+	stats := WriteStats{Policy: o.policy}
+	if q := o.queue; q != nil {
+		stats.QueueDepth = q.depth()
+		stats.QueueSize = q.size
+		stats.Coalesced = q.coalesced.Load()
+		stats.Dropped = q.dropped.Load()
+		stats.Flushed = q.flushed.Load()
+		stats.Failed = q.failed.Load()
+	}
+	return stats
+}
+
+// RunWriteBehind flushes the write-behind queue until ctx is done, then
+// drains what is left. It returns at once under the other policies.
+func (o *Orders) RunWriteBehind(ctx context.Context) {
+	q := o.queue
+	if q == nil {
+		return
+	}
+
+	ticker := time.NewTicker(o.flushInterval)
+	defer ticker.Stop()
+
+	var dropped int64
+	for {
+		select {
+		case <-ctx.Done():
+			drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+			defer cancel()
+			o.flushQueue(drainCtx)
+			return
+		case <-ticker.C:
+		case <-q.full:
+		}
+
+		o.flushQueue(ctx)
+
+		if d := q.dropped.Load(); d > dropped {
+			log.Printf("Dropped %d cache writes: the write-behind queue is full", d-dropped)
+			dropped = d
+		}
+	}
+}
+
+func (o *Orders) flushQueue(ctx context.Context) {
+	for {
+		writes := o.queue.take(o.flushBatch)
+		if len(writes) == 0 {
+			return
+		}
+
+		// A failed batch is not retried: the orders are in the database, and
+		// the next read or reconcile caches them.
+		if err := o.write(ctx, writes); err != nil {
+			log.Printf("Failed to flush %d cache writes: %v", len(writes), err)
+			o.queue.failed.Add(int64(len(writes)))
+			continue
+		}
+		o.queue.flushed.Add(int64(len(writes)))
+	}
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"l0/internal/config"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(policy string, queueSize int) config.Redis {
+	cfg := testRedisConfig()
+	cfg.Write = config.CacheWrite{Policy: policy, QueueSize: queueSize, BatchSize: 2, FlushInterval: time.Hour}
+	return cfg
+}
+
+func TestWriteBehindCoalescesAndDrops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	orders := NewOrders(NewMemory(), writeConfig(PolicyWriteBehind, 2))
+
+	first := testOrder("a")
+	first.TrackNumber = "first"
+	second := testOrder("a")
+	second.TrackNumber = "second"
+
+	require.NoError(t, orders.Set(ctx, first))
+	require.NoError(t, orders.Set(ctx, second))
+	require.NoError(t, orders.Set(ctx, testOrder("b")))
+	require.NoError(t, orders.Set(ctx, testOrder("c")))
+
+	_, err := orders.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss, "nothing is written before a flush")
+
+	stats := orders.WriteStats()
+	assert.Equal(t, 2, stats.QueueDepth)
+	assert.Equal(t, int64(1), stats.Coalesced)
+	assert.Equal(t, int64(1), stats.Dropped)
+
+	// Stopping drains the queue.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		orders.RunWriteBehind(ctx)
+	}()
+	cancel()
+	<-done
+
+	a, err := orders.Get(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, "second", a.TrackNumber)
+	_, err = orders.Get(context.Background(), "b")
+	assert.NoError(t, err)
+	_, err = orders.Get(context.Background(), "c")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	stats = orders.WriteStats()
+	assert.Zero(t, stats.QueueDepth)
+	assert.Equal(t, int64(2), stats.Flushed)
+}
+
+func TestWriteBehindDropInvalidatesCachedCopy(t *testing.T) {
+	ctx := context.Background()
+	orders := NewOrders(NewMemory(), writeConfig(PolicyWriteBehind, 1))
+
+	old := testOrder("b")
+	old.TrackNumber = "old"
+	require.NoError(t, orders.Warm(ctx, []model.Order{old}))
+
+	require.NoError(t, orders.Set(ctx, testOrder("a")))
+	require.NoError(t, orders.Set(ctx, testOrder("b")))
+	assert.Equal(t, int64(1), orders.WriteStats().Dropped)
+
+	_, err := orders.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss, "a dropped write must not leave the old version cached")
+}
+
+func TestWriteBehindDeleteDropsQueuedWrite(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	orders := NewOrders(NewMemory(), writeConfig(PolicyWriteBehind, 10))
+
+	require.NoError(t, orders.Set(ctx, testOrder("a")))
+	require.NoError(t, orders.Delete(ctx, "a"))
+
+	cancel()
+	orders.RunWriteBehind(ctx)
+
+	_, err := orders.Get(context.Background(), "a")
+	assert.ErrorIs(t, err, ErrMiss)
+}
+
+func TestWriteBehindClearsNegativeEntryAtOnce(t *testing.T) {
+	ctx := context.Background()
+	orders := NewOrders(NewMemory(), writeConfig(PolicyWriteBehind, 10))
+
+	require.NoError(t, orders.SetMissing(ctx, "a"))
+	require.NoError(t, orders.Set(ctx, testOrder("a")))
+
+	missing, err := orders.IsMissing(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, missing, "a queued order is not missing")
+	assert.Equal(t, 1, orders.WriteStats().QueueDepth)
+}
+
+func TestReadOnlyWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemory()
+	orders := NewOrders(backend, writeConfig(PolicyReadOnly, 0))
+
+	require.NoError(t, orders.Set(ctx, testOrder("a")))
+	require.NoError(t, orders.Warm(ctx, []model.Order{testOrder("b")}))
+	require.NoError(t, orders.SetMissing(ctx, "c"))
+
+	var keys []string
+	require.NoError(t, backend.Scan(ctx, "", func(k []string) error {
+		keys = append(keys, k...)
+		return nil
+	}))
+	assert.Empty(t, keys)
+	assert.True(t, orders.ReadOnly())
+}
+
+func TestReadOnlyInvalidates(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemory()
+	writer := NewOrders(backend, writeConfig(PolicyWriteThrough, 0))
+	orders := NewOrders(backend, writeConfig(PolicyReadOnly, 0))
+
+	stale := testOrder("a")
+	stale.TrackNumber = "stale"
+	require.NoError(t, writer.Set(ctx, stale))
+	require.NoError(t, writer.SetMissing(ctx, "b"))
+
+	require.NoError(t, orders.Set(ctx, testOrder("a")))
+	require.NoError(t, orders.Set(ctx, testOrder("b")))
+
+	// Nothing is written, but nothing outdated is left behind either.
+	_, err := writer.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+	missing, err := writer.IsMissing(ctx, "b")
+	require.NoError(t, err)
+	assert.False(t, missing)
+}
@@ -14,7 +14,9 @@ type Config struct {
 	StoragePath   string        `yaml:"storage_path"`
 	HTTPServer    HTTPServer    `yaml:"http_server"`
 	Database      Database      `yaml:"database"`
+	Sharding      Sharding      `yaml:"sharding"`
 	Redis         Redis         `yaml:"redis"`
+	LocalCache    LocalCache    `yaml:"local_cache"`
 	NatsStreaming NatsStreaming `yaml:"nats-streaming"`
 }
 
@@ -27,6 +29,7 @@ type HTTPServer struct {
 }
 
 type Database struct {
+	Driver   string `yaml:"driver" env-default:"postgres"`
 	Host     string `yaml:"host"`
 	Port     string `yaml:"port"`
 	User     string `yaml:"user"`
@@ -34,11 +37,114 @@ type Database struct {
 	Dbname   string `yaml:"dbname"`
 }
 
+type Sharding struct {
+	Map    string         `yaml:"map" env-default:"modulo"`
+	Shards []Database     `yaml:"shards"`
+	Static map[string]int `yaml:"static"`
+}
+
+// Redis configures the order cache. Backend picks where it lives: redis,
+// memory (in-process, for tests and local runs) or none.
 type Redis struct {
+	Backend  string `yaml:"backend" env-default:"redis"`
 	Host     string `yaml:"host"`
 	Port     string `yaml:"port"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	// Mode is single, sentinel (Addrs are the sentinels and MasterName the
+	// group they watch) or cluster (Addrs are seed nodes). Host and Port are
+	// the only address when Addrs is empty.
+	Mode             string        `yaml:"mode" env-default:"single"`
+	Addrs            []string      `yaml:"addrs"`
+	MasterName       string        `yaml:"master_name"`
+	SentinelUser     string        `yaml:"sentinel_user"`
+	SentinelPassword string        `yaml:"sentinel_password"`
+	TLS              RedisTLS      `yaml:"tls"`
+	DialTimeout      time.Duration `yaml:"dial_timeout" env-default:"5s"`
+	ReadTimeout      time.Duration `yaml:"read_timeout" env-default:"3s"`
+	WriteTimeout     time.Duration `yaml:"write_timeout" env-default:"3s"`
+	PoolSize         int           `yaml:"pool_size"`
+	MinIdleConns     int           `yaml:"min_idle_conns"`
+	KeyPrefix        string        `yaml:"key_prefix" env-default:"l0"`
+	KeyVersion       string        `yaml:"key_version" env-default:"v1"`
+	// Codec encodes new entries; entries of every codec stay readable.
+	Codec string `yaml:"codec" env-default:"json"`
+	// Indexes keeps sorted sets of order ids by customer, track number,
+	// delivery service and creation time.
+	Indexes bool `yaml:"indexes" env-default:"true"`
+	// IndexRetention is how far back from now the indexes reach: older
+	// orders are pruned from them and idle indexes expire. Zero keeps
+	// orders of any age.
+	IndexRetention time.Duration `yaml:"index_retention" env-default:"720h"`
+	TTL            CacheTTL      `yaml:"ttl"`
+	Stampede       Stampede      `yaml:"stampede"`
+	Reconcile      Reconcile     `yaml:"reconcile"`
+	Write          CacheWrite    `yaml:"write"`
+}
+
+// CacheWrite is how a process writes orders to the cache: write-through,
+// write-behind (queued and flushed in batches) or read-only. Processes share
+// the config file, so the policy is usually set per process through
+// CACHE_WRITE_POLICY. Deletes are never delayed or skipped.
+type CacheWrite struct {
+	Policy        string        `yaml:"policy" env:"CACHE_WRITE_POLICY" env-default:"write-through"`
+	QueueSize     int           `yaml:"queue_size" env-default:"10000"`
+	BatchSize     int           `yaml:"batch_size" env-default:"100"`
+	FlushInterval time.Duration `yaml:"flush_interval" env-default:"100ms"`
+}
+
+// RedisTLS enables TLS towards Redis. Without CAFile the system roots are
+// trusted; CertFile and KeyFile add a client certificate.
+type RedisTLS struct {
+	Enabled    bool   `yaml:"enabled"`
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+}
+
+// Reconcile schedules the consumer's comparison of the cache with the
+// database. A zero Interval disables it. Orders missing from the cache are
+// only added back when they were written within Lookback.
+type Reconcile struct {
+	Interval time.Duration `yaml:"interval" env-default:"10m"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"5m"`
+	Lookback time.Duration `yaml:"lookback" env-default:"1h"`
+}
+
+// Stampede configures the Redis lock that lets only one API replica load a
+// missing order from the database. Within a process misses are always
+// coalesced; the lock extends that across replicas.
+type Stampede struct {
+	Lock         bool          `yaml:"lock"`
+	LockTTL      time.Duration `yaml:"lock_ttl" env-default:"5s"`
+	Wait         time.Duration `yaml:"wait" env-default:"2s"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"50ms"`
+}
+
+// CacheTTL is the expiration policy of cached orders. Orders created within
+// RecentWindow get Recent instead of Default; with Sliding a read renews the
+// key for Hot. Jitter spreads expirations by up to that fraction of the TTL.
+type CacheTTL struct {
+	Default      time.Duration `yaml:"default" env-default:"24h"`
+	Recent       time.Duration `yaml:"recent"`
+	RecentWindow time.Duration `yaml:"recent_window"`
+	Hot          time.Duration `yaml:"hot"`
+	Sliding      bool          `yaml:"sliding"`
+	Jitter       float64       `yaml:"jitter"`
+	// Missing is how long an order id the database does not know is
+	// remembered as unknown. Zero disables negative caching.
+	Missing time.Duration `yaml:"missing" env-default:"30s"`
+}
+
+// LocalCache is the in-process layer in front of Redis. Its TTL should stay
+// well below the Redis one: it bounds staleness when an invalidation is lost.
+type LocalCache struct {
+	Enabled    bool          `yaml:"enabled"`
+	MaxEntries int           `yaml:"max_entries" env-default:"10000"`
+	MaxBytes   int64         `yaml:"max_bytes" env-default:"67108864"`
+	TTL        time.Duration `yaml:"ttl" env-default:"30s"`
 }
 
 type NatsStreaming struct {
@@ -0,0 +1,55 @@
+// Package domain holds the error kinds shared by the repository, cache and
+// service layers. Errors are wrapped as they travel up, and callers, the
+// HTTP handlers in particular, tell them apart with errors.Is instead of
+// knowing which layer failed.
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrOrderNotFound: no order has the requested order_uid.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrConflict: the request contradicts the current state, like a status
+	// change the lifecycle does not allow or a concurrent update.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalid: the request itself is malformed.
+	ErrInvalid = errors.New("invalid request")
+	// ErrUnavailable: a backing service cannot be reached; retrying later
+	// may succeed.
+	ErrUnavailable = errors.New("temporarily unavailable")
+)
+
+// Error is an error of a kind with a message meant for clients. It matches
+// its kind, and whatever the kind wraps, with errors.Is.
+type Error struct {
+	Kind error
+	Msg  string
+}
+
+func Errorf(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Detail is the message shown to clients.
+func (e *Error) Detail() string {
+	return e.Msg
+}
+
+// Unavailable marks err as a failure to reach a backing service.
+func Unavailable(err error) error {
+	if err == nil || errors.Is(err, ErrUnavailable) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
@@ -0,0 +1,26 @@
+package cachestats
+
+import (
+	"log/slog"
+	"net/http"
+
+	"l0/internal/cache"
+	resp "l0/internal/lib/api/response"
+
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	resp.Response
+	cache.Stats
+}
+
+type StatsGetter interface {
+	CacheStats() cache.Stats
+}
+
+func Get(logger *slog.Logger, getter StatsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, Response{Response: *resp.OK(), Stats: getter.CacheStats()})
+	}
+}
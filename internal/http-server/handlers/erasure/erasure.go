@@ -0,0 +1,104 @@
+package erasure
+
+import (
+	"log/slog"
+	"net/http"
+
+	"l0/internal/lib/api/problem"
+	resp "l0/internal/lib/api/response"
+	"l0/internal/model"
+
+	"github.com/go-chi/render"
+)
+
+type Request struct {
+	CustomerID string `json:"customer_id"`
+	OrderUID   string `json:"order_uid"`
+}
+
+type Response struct {
+	resp.Response
+	Erasure *model.ErasureRecord `json:"erasure,omitempty"`
+}
+
+type VerifyResponse struct {
+	resp.Response
+	Verified int `json:"verified"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=Eraser
+type Eraser interface {
+	Erase(req model.ErasureRequest) (model.ErasureRecord, error)
+	VerifyLog() (int, error)
+}
+
+// Erase anonymizes a customer or a single order. An erasure that committed
+// but left cached copies behind is still reported with its record: running
+// it again would not find the subject any more. The copies expire with
+// their TTL.
+func Erase(logger *slog.Logger, eraser Eraser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			problem.Invalid(w, r, "failed to decode request")
+			return
+		}
+
+		if (req.CustomerID == "") == (req.OrderUID == "") {
+			problem.Invalid(w, r, "exactly one of customer_id or order_uid is required")
+			return
+		}
+
+		erasure := model.ErasureRequest{SubjectType: model.SubjectCustomer, SubjectID: req.CustomerID}
+		if req.OrderUID != "" {
+			erasure = model.ErasureRequest{SubjectType: model.SubjectOrder, SubjectID: req.OrderUID}
+		}
+
+		erasure.Source = model.SourceHTTP
+		erasure.RequestedBy, _, _ = r.BasicAuth()
+		if erasure.RequestedBy == "" {
+			erasure.RequestedBy = r.RemoteAddr
+		}
+
+		record, err := eraser.Erase(erasure)
+		if err != nil && record.ID == 0 {
+			if problem.Status(err) >= http.StatusInternalServerError {
+				logger.Error("ошибка стирания данных покупателя",
+					slog.String("subject_type", string(erasure.SubjectType)),
+					slog.String("subject_id", erasure.SubjectID),
+					slog.String("err", err.Error()),
+				)
+			}
+			problem.Write(w, r, err)
+			return
+		}
+		if err != nil {
+			logger.Error("данные стёрты, но копии в кэше не удалены",
+				slog.Int64("erasure_id", record.ID),
+				slog.String("err", err.Error()),
+			)
+		}
+
+		logger.Info("customer data erased",
+			slog.Int64("erasure_id", record.ID),
+			slog.Int("orders", len(record.OrderUIDs)),
+		)
+
+		render.JSON(w, r, Response{Response: *resp.OK(), Erasure: &record})
+	}
+}
+
+// Verify checks the hash chain of the erasure log. A broken chain is a
+// conflict whose detail names the first bad entry.
+func Verify(logger *slog.Logger, eraser Eraser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		verified, err := eraser.VerifyLog()
+		if err != nil {
+			logger.Error("erasure log verification failed", slog.Int("verified", verified), slog.String("err", err.Error()))
+			problem.Write(w, r, err)
+			return
+		}
+
+		render.JSON(w, r, VerifyResponse{Response: *resp.OK(), Verified: verified})
+	}
+}
@@ -0,0 +1,192 @@
+package erasure_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"l0/internal/domain"
+	"l0/internal/http-server/handlers/erasure"
+	"l0/internal/http-server/handlers/erasure/mocks"
+	"l0/internal/lib/api/problem"
+	"l0/internal/model"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// router mounts the handlers the way the api does, behind basic auth.
+func router(eraser erasure.Eraser) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.BasicAuth("admin", map[string]string{"admin": "secret"}))
+	r.Post("/admin/erasures", erasure.Erase(logger, eraser))
+	r.Get("/admin/erasures/verify", erasure.Verify(logger, eraser))
+	return r
+}
+
+func TestEraseAuth(t *testing.T) {
+	for name, setAuth := range map[string]func(*http.Request){
+		"No Credentials":   func(*http.Request) {},
+		"Wrong Password":   func(r *http.Request) { r.SetBasicAuth("admin", "guess") },
+		"Unknown Operator": func(r *http.Request) { r.SetBasicAuth("root", "secret") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/admin/erasures", strings.NewReader(`{"customer_id":"c1"}`))
+			setAuth(req)
+
+			w := httptest.NewRecorder()
+			router(mocks.NewEraser(t)).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+func TestErase(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantRequest *model.ErasureRequest
+		mockReturn  model.ErasureRecord
+		mockError   error
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name: "Customer",
+			body: `{"customer_id":"c1"}`,
+			wantRequest: &model.ErasureRequest{
+				SubjectType: model.SubjectCustomer, SubjectID: "c1", RequestedBy: "admin", Source: model.SourceHTTP,
+			},
+			mockReturn: model.ErasureRecord{ID: 7, OrderUIDs: []string{"o1", "o2"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"order_uids":["o1","o2"]`,
+		},
+		{
+			name: "Order",
+			body: `{"order_uid":"o1"}`,
+			wantRequest: &model.ErasureRequest{
+				SubjectType: model.SubjectOrder, SubjectID: "o1", RequestedBy: "admin", Source: model.SourceHTTP,
+			},
+			mockReturn: model.ErasureRecord{ID: 8, OrderUIDs: []string{"o1"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"id":8`,
+		},
+		{
+			name:       "Bad Body",
+			body:       `{"customer_id":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "No Subject",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Both Subjects",
+			body:       `{"customer_id":"c1","order_uid":"o1"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Internal Error",
+			body: `{"customer_id":"c1"}`,
+			wantRequest: &model.ErasureRequest{
+				SubjectType: model.SubjectCustomer, SubjectID: "c1", RequestedBy: "admin", Source: model.SourceHTTP,
+			},
+			mockError:  errors.New("some db error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Invalid Request",
+			body: `{"customer_id":"c1"}`,
+			wantRequest: &model.ErasureRequest{
+				SubjectType: model.SubjectCustomer, SubjectID: "c1", RequestedBy: "admin", Source: model.SourceHTTP,
+			},
+			mockError:  domain.Errorf(domain.ErrInvalid, "requested_by is required"),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"detail":"requested_by is required"`,
+		},
+		{
+			name: "Cache Not Purged",
+			body: `{"customer_id":"c1"}`,
+			wantRequest: &model.ErasureRequest{
+				SubjectType: model.SubjectCustomer, SubjectID: "c1", RequestedBy: "admin", Source: model.SourceHTTP,
+			},
+			mockReturn: model.ErasureRecord{ID: 9, OrderUIDs: []string{"o1"}},
+			mockError:  errors.New("some db error"),
+			wantStatus: http.StatusOK,
+			wantBody:   `"id":9`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			eraser := mocks.NewEraser(t)
+			if tc.wantRequest != nil {
+				eraser.On("Erase", *tc.wantRequest).Return(tc.mockReturn, tc.mockError)
+			}
+
+			req := httptest.NewRequest("POST", "/admin/erasures", strings.NewReader(tc.body))
+			req.SetBasicAuth("admin", "secret")
+
+			w := httptest.NewRecorder()
+			router(eraser).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+			assert.NotContains(t, w.Body.String(), "some db error")
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		eraser := mocks.NewEraser(t)
+		eraser.On("VerifyLog").Return(3, nil)
+
+		req := httptest.NewRequest("GET", "/admin/erasures/verify", nil)
+		req.SetBasicAuth("admin", "secret")
+
+		w := httptest.NewRecorder()
+		router(eraser).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"verified":3`)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		eraser := mocks.NewEraser(t)
+		eraser.On("VerifyLog").Return(1, domain.Errorf(domain.ErrConflict, "erasure log entry 2: hash does not match its contents"))
+
+		req := httptest.NewRequest("GET", "/admin/erasures/verify", nil)
+		req.SetBasicAuth("admin", "secret")
+
+		w := httptest.NewRecorder()
+		router(eraser).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), `entry 2`)
+	})
+
+	t.Run("Internal Error", func(t *testing.T) {
+		eraser := mocks.NewEraser(t)
+		eraser.On("VerifyLog").Return(0, errors.New("some db error"))
+
+		req := httptest.NewRequest("GET", "/admin/erasures/verify", nil)
+		req.SetBasicAuth("admin", "secret")
+
+		w := httptest.NewRecorder()
+		router(eraser).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "some db error")
+	})
+}
@@ -0,0 +1,44 @@
+package order
+
+import (
+	"log/slog"
+	"net/http"
+
+	"l0/internal/lib/api/problem"
+	resp "l0/internal/lib/api/response"
+	"l0/internal/model"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type HistoryResponse struct {
+	resp.Response
+	History []model.OrderVersion `json:"history"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=HistoryGetter
+type HistoryGetter interface {
+	GetOrderHistory(id string) ([]model.OrderVersion, error)
+}
+
+func GetOrderHistory(logger *slog.Logger, historyGetter HistoryGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			problem.Invalid(w, r, "id is required")
+			return
+		}
+
+		history, err := historyGetter.GetOrderHistory(id)
+		if err != nil {
+			if problem.Status(err) >= http.StatusInternalServerError {
+				logger.Error("ошибка получения истории заказа", slog.String("id", id), slog.String("err", err.Error()))
+			}
+			problem.Write(w, r, err)
+			return
+		}
+
+		render.JSON(w, r, HistoryResponse{Response: *resp.OK(), History: history})
+	}
+}
@@ -0,0 +1,81 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"l0/internal/http-server/handlers/order"
+	"l0/internal/http-server/handlers/order/mocks"
+	"l0/internal/lib/diff"
+	"l0/internal/model"
+
+	"log/slog"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrderHistory(t *testing.T) {
+	tests := []struct {
+		name            string
+		id              string
+		mockReturn      []model.OrderVersion
+		mockReturnError error
+		wantStatus      int
+		wantBody        string
+	}{
+		{
+			name: "OK",
+			id:   "order123",
+			mockReturn: []model.OrderVersion{{
+				OrderUID: "order123",
+				Version:  2,
+				Source:   model.ChangeSource{Kind: model.SourceNATS, Ref: "42"},
+				Diff:     []diff.Change{{Path: "delivery.city", Old: "Moscow", New: "Kazan"}},
+				Snapshot: []byte(`{}`),
+			}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"path":"delivery.city"`,
+		},
+		{
+			name:       "Empty",
+			id:         "missing123",
+			mockReturn: []model.OrderVersion{},
+			wantStatus: http.StatusOK,
+			wantBody:   `"history":[]`,
+		},
+		{
+			name:            "Internal Error",
+			id:              "error123",
+			mockReturnError: errors.New("some db error"),
+			wantStatus:      http.StatusInternalServerError,
+			wantBody:        `"status":500`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockGetter := mocks.NewHistoryGetter(t)
+			mockGetter.On("GetOrderHistory", tc.id).Return(tc.mockReturn, tc.mockReturnError)
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			handler := order.GetOrderHistory(logger, mockGetter)
+
+			req := httptest.NewRequest("GET", "/order/"+tc.id+"/history", nil)
+
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", tc.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"l0/internal/lib/api/problem"
+	resp "l0/internal/lib/api/response"
+	"l0/internal/model"
+	"l0/internal/repository"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// Sort orders of GET /orders.
+const (
+	SortNewest = "-date_created"
+	SortOldest = "date_created"
+)
+
+type ListResponse struct {
+	resp.Response
+	Orders []model.Order `json:"orders"`
+	Next   string        `json:"next,omitempty"`
+	Total  *int          `json:"total,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=OrderLister
+type OrderLister interface {
+	ListOrders(ctx context.Context, f repository.OrderFilter, p repository.OrderPage) ([]model.Order, error)
+	CountOrders(ctx context.Context, f repository.OrderFilter) (int, error)
+}
+
+// ListOrders serves a page of orders. The next link carries an opaque cursor
+// and every other parameter of the request, so following it continues the
+// same listing; the total is only counted when asked for with total=true.
+func ListOrders(logger *slog.Logger, lister OrderLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		f, err := listFilter(q.Get)
+		if err != nil {
+			problem.Invalid(w, r, err.Error())
+			return
+		}
+
+		p := repository.OrderPage{}
+		switch q.Get("sort") {
+		case "", SortNewest:
+		case SortOldest:
+			p.Oldest = true
+		default:
+			problem.Invalid(w, r, "sort must be "+SortNewest+" or "+SortOldest)
+			return
+		}
+
+		p.Limit, err = intParam(r, "limit", defaultListLimit)
+		if err != nil || p.Limit <= 0 || p.Limit > maxListLimit {
+			problem.Invalid(w, r, "limit must be between 1 and "+strconv.Itoa(maxListLimit))
+			return
+		}
+
+		if token := q.Get("cursor"); token != "" {
+			if p.After, err = repository.ParseCursor(token); err != nil {
+				problem.Write(w, r, err)
+				return
+			}
+		}
+
+		withTotal, err := boolParam(r, "total")
+		if err != nil {
+			problem.Invalid(w, r, "total must be a boolean")
+			return
+		}
+
+		// One order more than asked tells whether there is a next page.
+		limit := p.Limit
+		p.Limit++
+		orders, err := lister.ListOrders(r.Context(), f, p)
+		if err != nil {
+			if problem.Status(err) >= http.StatusInternalServerError {
+				logger.Error("ошибка получения списка заказов", slog.String("err", err.Error()))
+			}
+			problem.Write(w, r, err)
+			return
+		}
+
+		res := ListResponse{Response: *resp.OK(), Orders: orders}
+		if len(orders) > limit {
+			res.Orders = orders[:limit]
+
+			next := r.URL.Query()
+			next.Set("cursor", repository.CursorAt(res.Orders[limit-1]).Token())
+			res.Next = r.URL.Path + "?" + next.Encode()
+		}
+
+		if withTotal {
+			total, err := lister.CountOrders(r.Context(), f)
+			if err != nil {
+				if problem.Status(err) >= http.StatusInternalServerError {
+					logger.Error("ошибка подсчёта заказов", slog.String("err", err.Error()))
+				}
+				problem.Write(w, r, err)
+				return
+			}
+			res.Total = &total
+		}
+
+		render.JSON(w, r, res)
+	}
+}
+
+func listFilter(get func(string) string) (repository.OrderFilter, error) {
+	f := repository.OrderFilter{
+		CustomerID:      get("customer_id"),
+		TrackNumber:     get("track_number"),
+		DeliveryService: get("delivery_service"),
+		Brand:           get("brand"),
+	}
+
+	var err error
+	if f.From, err = timeParam(get, "from"); err != nil {
+		return f, err
+	}
+	if f.To, err = timeParam(get, "to"); err != nil {
+		return f, err
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return f, errors.New("from must be before to")
+	}
+	return f, nil
+}
+
+func timeParam(get func(string) string, name string) (time.Time, error) {
+	raw := get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, errors.New(name + " must be an RFC 3339 time")
+	}
+	return t, nil
+}
+
+func boolParam(r *http.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(raw)
+}
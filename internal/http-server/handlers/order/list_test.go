@@ -0,0 +1,111 @@
+package order_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"l0/internal/http-server/handlers/order"
+	"l0/internal/http-server/handlers/order/mocks"
+	"l0/internal/model"
+	"l0/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOrders(t *testing.T) {
+	created := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	orders := []model.Order{
+		{OrderUID: "c", DateCreated: created.Add(2 * time.Hour)},
+		{OrderUID: "b", DateCreated: created.Add(time.Hour)},
+		{OrderUID: "a", DateCreated: created},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("First Page", func(t *testing.T) {
+		filter := repository.OrderFilter{CustomerID: "test", Brand: "Acme", From: created}
+		lister := mocks.NewOrderLister(t)
+		lister.On("ListOrders", mock.Anything, filter, repository.OrderPage{Limit: 3}).Return(orders, nil)
+		lister.On("CountOrders", mock.Anything, filter).Return(7, nil)
+
+		req := httptest.NewRequest("GET", "/orders?customer_id=test&brand=Acme&from=2025-07-01T00:00:00Z&limit=2&total=true", nil)
+		rr := httptest.NewRecorder()
+		order.ListOrders(logger, lister).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var res struct {
+			Orders []struct {
+				OrderUID string `json:"order_uid"`
+			} `json:"orders"`
+			Next  string `json:"next"`
+			Total *int   `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		require.Len(t, res.Orders, 2)
+		assert.Equal(t, "b", res.Orders[1].OrderUID)
+		require.NotNil(t, res.Total)
+		assert.Equal(t, 7, *res.Total)
+
+		next, err := url.Parse(res.Next)
+		require.NoError(t, err)
+		assert.Equal(t, "/orders", next.Path)
+		assert.Equal(t, "test", next.Query().Get("customer_id"))
+		cursor, err := repository.ParseCursor(next.Query().Get("cursor"))
+		require.NoError(t, err)
+		assert.Equal(t, repository.CursorAt(orders[1]), cursor)
+	})
+
+	t.Run("Last Page", func(t *testing.T) {
+		after := repository.CursorAt(orders[1])
+		lister := mocks.NewOrderLister(t)
+		lister.On("ListOrders", mock.Anything, repository.OrderFilter{}, repository.OrderPage{After: after, Oldest: true, Limit: 21}).
+			Return(orders[2:], nil)
+
+		req := httptest.NewRequest("GET", "/orders?sort=date_created&cursor="+after.Token(), nil)
+		rr := httptest.NewRecorder()
+		order.ListOrders(logger, lister).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), `"next"`)
+		assert.NotContains(t, rr.Body.String(), `"total"`)
+	})
+
+	for _, tc := range []struct {
+		name  string
+		query string
+	}{
+		{"Bad Sort", "sort=amount"},
+		{"Bad Limit", "limit=1000"},
+		{"Bad Cursor", "cursor=xyz"},
+		{"Bad Time", "from=yesterday"},
+		{"Empty Range", "from=2025-07-02T00:00:00Z&to=2025-07-01T00:00:00Z"},
+		{"Bad Total", "total=maybe"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/orders?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			order.ListOrders(logger, mocks.NewOrderLister(t)).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	t.Run("Internal Error", func(t *testing.T) {
+		lister := mocks.NewOrderLister(t)
+		lister.On("ListOrders", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("some db error"))
+
+		rr := httptest.NewRecorder()
+		order.ListOrders(logger, lister).ServeHTTP(rr, httptest.NewRequest("GET", "/orders", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"status":500`)
+	})
+}
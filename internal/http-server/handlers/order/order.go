@@ -1,15 +1,14 @@
 package order
 
 import (
-	"errors"
 	"html/template"
 	"log/slog"
 	"net/http"
 	"path/filepath"
 	"strings"
 
+	"l0/internal/lib/api/problem"
 	resp "l0/internal/lib/api/response"
-	"l0/internal/lib/storage"
 	"l0/internal/model"
 
 	"github.com/go-chi/chi"
@@ -41,55 +40,58 @@ func GetOrder(logger *slog.Logger, orderGetter ORDERGetter) http.HandlerFunc {
 		if id == "" {
 			id = r.URL.Query().Get("id")
 		}
+		accept := r.Header.Get("Accept")
+		isJSON := strings.Contains(accept, "application/json")
+
 		if id == "" {
-			renderTemplate(w, HTMLResponse{Error: "ID не указан"})
+			if isJSON {
+				problem.Invalid(w, r, "id is required")
+			} else {
+				renderTemplate(w, http.StatusBadRequest, HTMLResponse{Error: "ID не указан"})
+			}
 			return
 		}
 
 		order, err := orderGetter.GetOrderById(id)
-
-		accept := r.Header.Get("Accept")
-		isJSON := strings.Contains(accept, "application/json")
-
 		if err != nil {
-			if errors.Is(err, storage.ErrUrlNotFound) {
-				if isJSON {
-					w.WriteHeader(http.StatusNotFound)
-					render.JSON(w, r, resp.Error("not found"))
-				} else {
-					renderTemplate(w, HTMLResponse{Error: "Заказ не найден"})
-				}
-				return
+			status := problem.Status(err)
+			if status >= http.StatusInternalServerError {
+				logger.Error("ошибка получения заказа", slog.String("id", id), slog.String("err", err.Error()))
 			}
 
-			logger.Error("ошибка получения заказа", slog.String("id", id), slog.String("err", err.Error()))
-
 			if isJSON {
-				render.JSON(w, r, resp.Error("internal error"))
-			} else {
-				renderTemplate(w, HTMLResponse{Error: "Заказ не найден"})
+				problem.Write(w, r, err)
+				return
+			}
+
+			msg := "Не удалось получить заказ, попробуйте позже"
+			switch status {
+			case http.StatusNotFound:
+				msg = "Заказ не найден"
+			case http.StatusBadRequest:
+				msg = "Некорректный ID заказа"
 			}
+			renderTemplate(w, status, HTMLResponse{Error: msg})
 			return
 		}
 
 		if isJSON {
 			render.JSON(w, r, Response{Response: *resp.OK(), Order: order})
 		} else {
-			renderTemplate(w, HTMLResponse{Order: &order})
+			renderTemplate(w, http.StatusOK, HTMLResponse{Order: &order})
 		}
 	}
 }
 
-func renderTemplate(w http.ResponseWriter, data HTMLResponse) {
+func renderTemplate(w http.ResponseWriter, status int, data HTMLResponse) {
 	tmpl, err := template.ParseFiles(filepath.Join("internal", "http-server", "templates", "order.html"))
 	if err != nil {
 		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if data.Error != "" {
-		w.WriteHeader(http.StatusNotFound)
-	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
@@ -3,14 +3,15 @@ package order_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"l0/internal/domain"
 	"l0/internal/http-server/handlers/order"
 	"l0/internal/http-server/handlers/order/mocks"
-	"l0/internal/lib/storage"
 	"l0/internal/model"
 
 	"log/slog"
@@ -43,9 +44,9 @@ func TestGetOrder(t *testing.T) {
 			id:              "missing123",
 			acceptHeader:    "application/json",
 			mockReturnOrder: model.Order{},
-			mockReturnError: storage.ErrUrlNotFound,
+			mockReturnError: fmt.Errorf("storage: %w", domain.Errorf(domain.ErrOrderNotFound, "order missing123 not found")),
 			wantStatus:      http.StatusNotFound,
-			wantBody:        `"error":"not found"`,
+			wantBody:        `"detail":"order missing123 not found"`,
 		},
 		{
 			name:            "Internal Error",
@@ -53,8 +54,17 @@ func TestGetOrder(t *testing.T) {
 			acceptHeader:    "application/json",
 			mockReturnOrder: model.Order{},
 			mockReturnError: errors.New("some db error"),
-			wantStatus:      http.StatusOK,
-			wantBody:        `"error":"internal error"`,
+			wantStatus:      http.StatusInternalServerError,
+			wantBody:        `"status":500`,
+		},
+		{
+			name:            "Unavailable",
+			id:              "down123",
+			acceptHeader:    "application/json",
+			mockReturnOrder: model.Order{},
+			mockReturnError: domain.Unavailable(errors.New("connection refused")),
+			wantStatus:      http.StatusServiceUnavailable,
+			wantBody:        `"status":503`,
 		},
 	}
 
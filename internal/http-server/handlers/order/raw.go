@@ -0,0 +1,44 @@
+package order
+
+import (
+	"log/slog"
+	"net/http"
+
+	"l0/internal/lib/api/problem"
+	resp "l0/internal/lib/api/response"
+	"l0/internal/model"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type RawResponse struct {
+	resp.Response
+	Raw model.RawOrder `json:"raw"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=RawGetter
+type RawGetter interface {
+	GetRawOrder(id string) (model.RawOrder, error)
+}
+
+func GetRawOrder(logger *slog.Logger, rawGetter RawGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			problem.Invalid(w, r, "id is required")
+			return
+		}
+
+		raw, err := rawGetter.GetRawOrder(id)
+		if err != nil {
+			if problem.Status(err) >= http.StatusInternalServerError {
+				logger.Error("ошибка получения исходного сообщения", slog.String("id", id), slog.String("err", err.Error()))
+			}
+			problem.Write(w, r, err)
+			return
+		}
+
+		render.JSON(w, r, RawResponse{Response: *resp.OK(), Raw: raw})
+	}
+}
@@ -0,0 +1,94 @@
+package order_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"l0/internal/domain"
+	"l0/internal/http-server/handlers/order"
+	"l0/internal/http-server/handlers/order/mocks"
+	"l0/internal/model"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRawOrder(t *testing.T) {
+	raw := model.RawOrder{
+		OrderUID:    "order123",
+		Subject:     "l0",
+		Sequence:    42,
+		PublishedAt: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		ReceivedAt:  time.Date(2025, 7, 1, 0, 0, 1, 0, time.UTC),
+		Payload:     json.RawMessage(`{"order_uid":"order123","amount":"1817"}`),
+	}
+
+	tests := []struct {
+		name            string
+		id              string
+		mockReturn      model.RawOrder
+		mockReturnError error
+		wantStatus      int
+		wantBody        string
+	}{
+		{
+			name:       "OK",
+			id:         "order123",
+			mockReturn: raw,
+			wantStatus: http.StatusOK,
+			// The payload is served exactly as it was received.
+			wantBody: `"sequence":42,"published_at":"2025-07-01T00:00:00Z","received_at":"2025-07-01T00:00:01Z","payload":{"order_uid":"order123","amount":"1817"}`,
+		},
+		{
+			name:            "Not Found",
+			id:              "missing123",
+			mockReturnError: fmt.Errorf("storage: %w", domain.Errorf(domain.ErrOrderNotFound, "no raw message for order missing123")),
+			wantStatus:      http.StatusNotFound,
+			wantBody:        `"detail":"no raw message for order missing123"`,
+		},
+		{
+			name:            "Internal Error",
+			id:              "error123",
+			mockReturnError: errors.New("some db error"),
+			wantStatus:      http.StatusInternalServerError,
+			wantBody:        `"status":500`,
+		},
+		{
+			name:       "No ID",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"detail":"id is required"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			getter := mocks.NewRawGetter(t)
+			if tc.id != "" {
+				getter.On("GetRawOrder", tc.id).Return(tc.mockReturn, tc.mockReturnError)
+			}
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			handler := order.GetRawOrder(logger, getter)
+
+			req := httptest.NewRequest("GET", "/order/"+tc.id+"/raw", nil)
+
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", tc.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package order
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"l0/internal/lib/api/problem"
+	resp "l0/internal/lib/api/response"
+	"l0/internal/model"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchResponse struct {
+	resp.Response
+	Results []model.SearchResult `json:"results"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=OrderSearcher
+type OrderSearcher interface {
+	SearchOrders(query string, limit, offset int) ([]model.SearchResult, error)
+}
+
+func SearchOrders(logger *slog.Logger, searcher OrderSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			problem.Invalid(w, r, "query parameter q is required")
+			return
+		}
+
+		limit, err := intParam(r, "limit", defaultSearchLimit)
+		if err != nil || limit <= 0 || limit > maxSearchLimit {
+			problem.Invalid(w, r, "limit must be between 1 and "+strconv.Itoa(maxSearchLimit))
+			return
+		}
+
+		offset, err := intParam(r, "offset", 0)
+		if err != nil || offset < 0 {
+			problem.Invalid(w, r, "offset must be a non-negative integer")
+			return
+		}
+
+		results, err := searcher.SearchOrders(q, limit, offset)
+		if err != nil {
+			if problem.Status(err) >= http.StatusInternalServerError {
+				logger.Error("ошибка поиска заказов", slog.String("q", q), slog.String("err", err.Error()))
+			}
+			problem.Write(w, r, err)
+			return
+		}
+
+		render.JSON(w, r, SearchResponse{Response: *resp.OK(), Results: results})
+	}
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	return strconv.Atoi(raw)
+}
@@ -0,0 +1,93 @@
+package order_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"l0/internal/http-server/handlers/order"
+	"l0/internal/http-server/handlers/order/mocks"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchOrders(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	results := []model.SearchResult{
+		{
+			OrderUID:    "order123",
+			TrackNumber: "WBILMTESTTRACK",
+			CustomerID:  "test",
+			DateCreated: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+			Rank:        0.5,
+			Headline:    "<b>Mascaras</b>",
+		},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		searcher := mocks.NewOrderSearcher(t)
+		searcher.On("SearchOrders", "mascaras", 5, 10).Return(results, nil)
+
+		req := httptest.NewRequest("GET", "/orders/search?q=+mascaras+&limit=5&offset=10", nil)
+		rr := httptest.NewRecorder()
+		order.SearchOrders(logger, searcher).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var res order.SearchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.Equal(t, "OK", res.Status)
+		assert.Equal(t, results, res.Results)
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		searcher := mocks.NewOrderSearcher(t)
+		searcher.On("SearchOrders", "moscow", 20, 0).Return([]model.SearchResult{}, nil)
+
+		req := httptest.NewRequest("GET", "/orders/search?q=moscow", nil)
+		rr := httptest.NewRecorder()
+		order.SearchOrders(logger, searcher).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"results":[]`)
+	})
+
+	for _, tc := range []struct {
+		name  string
+		query string
+	}{
+		{"No Query", ""},
+		{"Blank Query", "q=+++"},
+		{"Zero Limit", "q=moscow&limit=0"},
+		{"Limit Too Large", "q=moscow&limit=101"},
+		{"Bad Limit", "q=moscow&limit=ten"},
+		{"Negative Offset", "q=moscow&offset=-1"},
+		{"Bad Offset", "q=moscow&offset=first"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/orders/search?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			order.SearchOrders(logger, mocks.NewOrderSearcher(t)).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), `"status":400`)
+		})
+	}
+
+	t.Run("Internal Error", func(t *testing.T) {
+		searcher := mocks.NewOrderSearcher(t)
+		searcher.On("SearchOrders", "moscow", 20, 0).Return(nil, errors.New("some db error"))
+
+		rr := httptest.NewRecorder()
+		order.SearchOrders(logger, searcher).ServeHTTP(rr, httptest.NewRequest("GET", "/orders/search?q=moscow", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "some db error")
+	})
+}
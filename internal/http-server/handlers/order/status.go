@@ -0,0 +1,92 @@
+package order
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"l0/internal/lib/api/problem"
+	resp "l0/internal/lib/api/response"
+	"l0/internal/model"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type StatusRequest struct {
+	Status model.OrderStatus `json:"status"`
+	Reason string            `json:"reason,omitempty"`
+}
+
+type StatusResponse struct {
+	resp.Response
+	Change model.StatusChange `json:"change"`
+}
+
+type StatusHistoryResponse struct {
+	resp.Response
+	History []model.StatusChange `json:"history"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=StatusChanger
+type StatusChanger interface {
+	ChangeStatus(ctx context.Context, id string, to model.OrderStatus, reason string) (model.StatusChange, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=StatusHistoryGetter
+type StatusHistoryGetter interface {
+	GetStatusHistory(ctx context.Context, id string) ([]model.StatusChange, error)
+}
+
+// ChangeStatus moves an order to the status in the request body. Unknown
+// statuses are rejected with 400, changes the lifecycle does not allow with
+// 409 and the statuses the order may move to.
+func ChangeStatus(logger *slog.Logger, changer StatusChanger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		var req StatusRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			problem.Invalid(w, r, "failed to decode request")
+			return
+		}
+		if !req.Status.Valid() {
+			problem.Invalid(w, r, "unknown order status \""+string(req.Status)+"\"")
+			return
+		}
+
+		change, err := changer.ChangeStatus(r.Context(), id, req.Status, req.Reason)
+		if err != nil {
+			if problem.Status(err) >= http.StatusInternalServerError {
+				logger.Error("ошибка смены статуса заказа", slog.String("id", id), slog.String("err", err.Error()))
+			}
+			problem.Write(w, r, err)
+			return
+		}
+
+		logger.Info("order status changed",
+			slog.String("id", id),
+			slog.String("from", string(change.From)),
+			slog.String("to", string(change.To)),
+		)
+
+		render.JSON(w, r, StatusResponse{Response: *resp.OK(), Change: change})
+	}
+}
+
+func GetStatusHistory(logger *slog.Logger, getter StatusHistoryGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		history, err := getter.GetStatusHistory(r.Context(), id)
+		if err != nil {
+			if problem.Status(err) >= http.StatusInternalServerError {
+				logger.Error("ошибка получения истории статусов", slog.String("id", id), slog.String("err", err.Error()))
+			}
+			problem.Write(w, r, err)
+			return
+		}
+
+		render.JSON(w, r, StatusHistoryResponse{Response: *resp.OK(), History: history})
+	}
+}
@@ -0,0 +1,99 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"l0/internal/domain"
+	"l0/internal/http-server/handlers/order"
+	"l0/internal/http-server/handlers/order/mocks"
+	"l0/internal/model"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChangeStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		mockReturn      model.StatusChange
+		mockReturnError error
+		wantStatus      int
+		wantBody        string
+	}{
+		{
+			name:       "OK",
+			body:       `{"status":"paid","reason":"card"}`,
+			mockReturn: model.StatusChange{OrderUID: "order123", From: model.StatusCreated, To: model.StatusPaid, Reason: "card"},
+			wantStatus: http.StatusOK,
+			wantBody:   `"from":"created","to":"paid"`,
+		},
+		{
+			name:       "Bad Body",
+			body:       `{"status":`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"detail":"failed to decode request"`,
+		},
+		{
+			name:       "Unknown Status",
+			body:       `{"status":"lost"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `unknown order status`,
+		},
+		{
+			name:            "Not Found",
+			body:            `{"status":"paid"}`,
+			mockReturnError: fmt.Errorf("storage: %w", domain.ErrOrderNotFound),
+			wantStatus:      http.StatusNotFound,
+			wantBody:        `"status":404`,
+		},
+		{
+			name:            "Illegal Transition",
+			body:            `{"status":"paid"}`,
+			mockReturnError: fmt.Errorf("storage: %w", &model.TransitionError{From: model.StatusShipped, To: model.StatusPaid}),
+			wantStatus:      http.StatusConflict,
+			wantBody:        `only to delivered, returned`,
+		},
+		{
+			name:            "Internal Error",
+			body:            `{"status":"paid"}`,
+			mockReturnError: errors.New("some db error"),
+			wantStatus:      http.StatusInternalServerError,
+			wantBody:        `"status":500`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			changer := mocks.NewStatusChanger(t)
+			if tc.mockReturn.To != "" || tc.mockReturnError != nil {
+				changer.On("ChangeStatus", mock.Anything, "order123", model.StatusPaid, mock.Anything).
+					Return(tc.mockReturn, tc.mockReturnError)
+			}
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			handler := order.ChangeStatus(logger, changer)
+
+			req := httptest.NewRequest("PATCH", "/order/order123/status", strings.NewReader(tc.body))
+
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", "order123")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package problem writes errors as RFC 7807 problem details.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"l0/internal/domain"
+)
+
+const ContentType = "application/problem+json"
+
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+var kinds = []struct {
+	err    error
+	status int
+}{
+	{domain.ErrOrderNotFound, http.StatusNotFound},
+	{domain.ErrInvalid, http.StatusBadRequest},
+	{domain.ErrConflict, http.StatusConflict},
+	{domain.ErrUnavailable, http.StatusServiceUnavailable},
+}
+
+// kind returns the domain error err is of, nil for internal errors.
+func kind(err error) (error, int) {
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return k.err, k.status
+		}
+	}
+	return nil, http.StatusInternalServerError
+}
+
+// Status maps an error to the HTTP status of its domain kind. Errors of no
+// kind are internal.
+func Status(err error) int {
+	_, status := kind(err)
+	return status
+}
+
+// From describes err for the client. Client errors carry the message of the
+// domain error that caused them, or else the name of their kind; server
+// errors never show their cause, which is for the logs.
+func From(r *http.Request, err error) Problem {
+	k, status := kind(err)
+	p := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: r.URL.Path,
+	}
+
+	if status < http.StatusInternalServerError {
+		var d interface{ Detail() string }
+		if errors.As(err, &d) {
+			p.Detail = d.Detail()
+		} else {
+			p.Detail = k.Error()
+		}
+	}
+	return p
+}
+
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := From(r, err)
+
+	w.Header().Set("Content-Type", ContentType)
+	if p.Status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "1")
+	}
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Invalid writes a 400 problem for a malformed request.
+func Invalid(w http.ResponseWriter, r *http.Request, detail string) {
+	Write(w, r, domain.Errorf(domain.ErrInvalid, "%s", detail))
+}
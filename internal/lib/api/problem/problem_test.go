@@ -0,0 +1,72 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"l0/internal/domain"
+	"l0/internal/lib/api/problem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail string
+	}{
+		{
+			name:       "Not Found",
+			err:        fmt.Errorf("storage: %w", domain.Errorf(domain.ErrOrderNotFound, "order abc not found")),
+			wantStatus: http.StatusNotFound,
+			wantDetail: "order abc not found",
+		},
+		{
+			name:       "Invalid",
+			err:        domain.ErrInvalid,
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "invalid request",
+		},
+		{
+			name:       "Conflict",
+			err:        fmt.Errorf("storage: %w", domain.Errorf(domain.ErrConflict, "changed concurrently")),
+			wantStatus: http.StatusConflict,
+			wantDetail: "changed concurrently",
+		},
+		{
+			name:       "Unavailable",
+			err:        fmt.Errorf("storage: %w", domain.Unavailable(errors.New("dial tcp: connection refused"))),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "Internal",
+			err:        errors.New("pq: syntax error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			problem.Write(w, httptest.NewRequest("GET", "/order/abc", nil), tc.err)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+			var p problem.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, tc.wantStatus, p.Status)
+			assert.Equal(t, http.StatusText(tc.wantStatus), p.Title)
+			assert.Equal(t, "/order/abc", p.Instance)
+			// Server errors keep their cause out of the response.
+			assert.Equal(t, tc.wantDetail, p.Detail)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Compare returns the leaf-level differences between the JSON forms of old
+// and new. Paths use dots for objects and indexes for arrays, e.g.
+// "items.0.price". A nil old value yields every field of new as a change.
+func Compare(old, new any) ([]Change, error) {
+	oldFlat, err := flatten(old)
+	if err != nil {
+		return nil, fmt.Errorf("diff: old value: %w", err)
+	}
+	newFlat, err := flatten(new)
+	if err != nil {
+		return nil, fmt.Errorf("diff: new value: %w", err)
+	}
+
+	var changes []Change
+	for path, nv := range newFlat {
+		ov, ok := oldFlat[path]
+		if !ok || !reflect.DeepEqual(ov, nv) {
+			changes = append(changes, Change{Path: path, Old: ov, New: nv})
+		}
+	}
+	for path, ov := range oldFlat {
+		if _, ok := newFlat[path]; !ok {
+			changes = append(changes, Change{Path: path, Old: ov})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func flatten(v any) (map[string]any, error) {
+	out := make(map[string]any)
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return out, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	walk("", generic, out)
+	return out, nil
+}
+
+func walk(prefix string, v any, out map[string]any) {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			walk(join(prefix, k), child, out)
+		}
+	case []any:
+		for i, child := range val {
+			walk(join(prefix, strconv.Itoa(i)), child, out)
+		}
+	default:
+		out[prefix] = val
+	}
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -1,8 +0,0 @@
-package storage
-
-import "errors"
-
-var (
-	ErrUrlNotFound = errors.New("url not found")
-	ErrUrlExists   = errors.New("url exists")
-)
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"l0/internal/domain"
+	"strings"
+	"time"
+)
+
+type ErasureSubject string
+
+const (
+	SubjectCustomer ErasureSubject = "customer_id"
+	SubjectOrder    ErasureSubject = "order_uid"
+)
+
+const (
+	ErasedValue        = "[erased]"
+	ErasureGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+type ErasureRequest struct {
+	SubjectType ErasureSubject `json:"subject_type"`
+	SubjectID   string         `json:"subject_id"`
+	RequestedBy string         `json:"requested_by"`
+	// Source is where the request came from, recorded with RequestedBy in
+	// the history of every erased order. It defaults to SourceAdmin.
+	Source SourceKind `json:"source,omitempty"`
+}
+
+type ErasureRecord struct {
+	ID          int64          `json:"id"`
+	SubjectType ErasureSubject `json:"subject_type"`
+	SubjectID   string         `json:"subject_id"`
+	OrderUIDs   []string       `json:"order_uids"`
+	RequestedBy string         `json:"requested_by"`
+	ErasedAt    time.Time      `json:"erased_at"`
+	PrevHash    string         `json:"prev_hash"`
+	Hash        string         `json:"hash"`
+}
+
+// ComputeHash chains the record to its predecessor, so editing or removing
+// any earlier entry breaks every hash after it.
+func (r ErasureRecord) ComputeHash() string {
+	h := sha256.New()
+	for _, part := range []string{
+		r.PrevHash,
+		string(r.SubjectType),
+		r.SubjectID,
+		strings.Join(r.OrderUIDs, ","),
+		r.RequestedBy,
+		r.ErasedAt.UTC().Format(time.RFC3339Nano),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyErasureLog walks the records in log order and reports the first one
+// whose hash does not match its contents or whose link to the record before
+// it is broken, along with the number of records verified before it. A broken
+// log is a domain.ErrConflict: it contradicts the state it should record.
+func VerifyErasureLog(records []ErasureRecord) (int, error) {
+	prev := ErasureGenesisHash
+	for i, r := range records {
+		if r.PrevHash != prev {
+			return i, domain.Errorf(domain.ErrConflict, "erasure log entry %d: previous hash does not match the entry before it", r.ID)
+		}
+		if r.ComputeHash() != r.Hash {
+			return i, domain.Errorf(domain.ErrConflict, "erasure log entry %d: hash does not match its contents", r.ID)
+		}
+		prev = r.Hash
+	}
+
+	return len(records), nil
+}
+
+// Anonymized returns the delivery with every field that identifies a person
+// replaced. City and region are kept: they are too coarse to identify anyone
+// on their own and are still needed for reporting.
+func (d Delivery) Anonymized() Delivery {
+	return Delivery{
+		Name:    ErasedValue,
+		Phone:   ErasedValue,
+		Zip:     ErasedValue,
+		City:    d.City,
+		Address: ErasedValue,
+		Region:  d.Region,
+		Email:   ErasedValue,
+	}
+}
@@ -0,0 +1,95 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"l0/internal/domain"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// erasureLog builds a correctly chained log of n records.
+func erasureLog(n int) []model.ErasureRecord {
+	records := make([]model.ErasureRecord, n)
+	prev := model.ErasureGenesisHash
+	for i := range records {
+		r := model.ErasureRecord{
+			ID:          int64(i + 1),
+			SubjectType: model.SubjectCustomer,
+			SubjectID:   "customer" + string(rune('a'+i)),
+			OrderUIDs:   []string{"order1", "order2"},
+			RequestedBy: "admin",
+			ErasedAt:    time.Date(2025, 7, 1, 12, i, 0, 0, time.UTC),
+			PrevHash:    prev,
+		}
+		r.Hash = r.ComputeHash()
+		records[i] = r
+		prev = r.Hash
+	}
+	return records
+}
+
+func TestErasureComputeHash(t *testing.T) {
+	r := erasureLog(1)[0]
+	assert.Len(t, r.Hash, 64)
+	assert.Equal(t, r.Hash, r.ComputeHash())
+
+	// The same instant in another zone hashes the same.
+	moved := r
+	moved.ErasedAt = r.ErasedAt.In(time.FixedZone("MSK", 3*60*60))
+	assert.Equal(t, r.Hash, moved.ComputeHash())
+
+	// Fields are separated, so moving a character from one to the next
+	// changes the hash.
+	shifted := r
+	shifted.SubjectID = "customer"
+	shifted.OrderUIDs = []string{"aorder1", "order2"}
+	assert.NotEqual(t, r.Hash, shifted.ComputeHash())
+
+	relinked := r
+	relinked.PrevHash = erasureLog(2)[1].Hash
+	assert.NotEqual(t, r.Hash, relinked.ComputeHash())
+}
+
+func TestVerifyErasureLog(t *testing.T) {
+	verified, err := model.VerifyErasureLog(erasureLog(3))
+	require.NoError(t, err)
+	assert.Equal(t, 3, verified)
+
+	verified, err = model.VerifyErasureLog(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, verified)
+
+	t.Run("Tampered Entry", func(t *testing.T) {
+		records := erasureLog(3)
+		records[1].OrderUIDs = []string{"order1"}
+
+		verified, err := model.VerifyErasureLog(records)
+		assert.EqualError(t, err, "erasure log entry 2: hash does not match its contents")
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		assert.Equal(t, 1, verified)
+	})
+
+	t.Run("Rehashed Entry", func(t *testing.T) {
+		// Recomputing the hash of an edited entry breaks the link of the next.
+		records := erasureLog(3)
+		records[1].RequestedBy = "someone else"
+		records[1].Hash = records[1].ComputeHash()
+
+		verified, err := model.VerifyErasureLog(records)
+		assert.EqualError(t, err, "erasure log entry 3: previous hash does not match the entry before it")
+		assert.Equal(t, 2, verified)
+	})
+
+	t.Run("Removed Entry", func(t *testing.T) {
+		records := erasureLog(3)
+		records = append(records[:1], records[2:]...)
+
+		verified, err := model.VerifyErasureLog(records)
+		assert.EqualError(t, err, "erasure log entry 3: previous hash does not match the entry before it")
+		assert.Equal(t, 1, verified)
+	})
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"l0/internal/lib/diff"
+)
+
+// SourceKind tells where an order change came from: a NATS message, whose
+// sequence is the ref, an HTTP caller or an operator of the admin CLI.
+type SourceKind string
+
+const (
+	SourceNATS  SourceKind = "nats"
+	SourceHTTP  SourceKind = "http"
+	SourceAdmin SourceKind = "admin"
+)
+
+type ChangeSource struct {
+	Kind SourceKind `json:"kind"`
+	Ref  string     `json:"ref,omitempty"`
+	// Raw is the message the change came from, if any; it is stored as-is
+	// next to the order.
+	Raw *RawOrder `json:"-"`
+}
+
+type OrderVersion struct {
+	OrderUID  string          `json:"order_uid"`
+	Version   int             `json:"version"`
+	Source    ChangeSource    `json:"source"`
+	ChangedAt time.Time       `json:"changed_at"`
+	Diff      []diff.Change   `json:"diff"`
+	Snapshot  json.RawMessage `json:"snapshot"`
+}
@@ -1,15 +1,27 @@
 package model
 
+type ItemStatus int
+
+const (
+	ItemStatusAccepted ItemStatus = 202
+)
+
+// Valid tells whether s looks like a status code. Codes other than accepted
+// are passed through from the feed unchanged.
+func (s ItemStatus) Valid() bool {
+	return s >= 100 && s <= 999
+}
+
 type Item struct {
-	ChrtID      int    `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	RID         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NMID        int    `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
+	ChrtID      int        `json:"chrt_id"`
+	TrackNumber string     `json:"track_number"`
+	Price       Money      `json:"price"`
+	RID         string     `json:"rid"`
+	Name        string     `json:"name"`
+	Sale        int        `json:"sale"`
+	Size        string     `json:"size"`
+	TotalPrice  Money      `json:"total_price"`
+	NMID        int        `json:"nm_id"`
+	Brand       string     `json:"brand"`
+	Status      ItemStatus `json:"status"`
 }
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Money is an amount in minor units (cents, kopecks). It carries no currency
+// of its own: all amounts of an order, item prices included, are in the
+// currency of its payment, which the feed sends once next to bare numbers.
+//
+// On the wire amounts stay in major units, as the feed has always sent them:
+// "amount": 1817 in USD is 1817 dollars, held as 181700 cents. A wire amount
+// may have as many decimals as the currency has minor units. Payment and
+// Order convert at the JSON boundary, since only they know the currency.
+type Money int64
+
+// parseAmount reads a wire amount in major units of c. An empty number is
+// zero, as for a missing field.
+func parseAmount(n json.Number, c Currency) (Money, error) {
+	if n == "" {
+		return 0, nil
+	}
+
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %s", n)
+	}
+	r.Mul(r, new(big.Rat).SetInt(minorScale(c)))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, fmt.Errorf("amount %s does not fit %d decimals of %q", n, c.MinorUnits(), c)
+	}
+	return Money(r.Num().Int64()), nil
+}
+
+// wire writes m in major units of c, without trailing zeros.
+func (m Money) wire(c Currency) json.Number {
+	s := new(big.Rat).SetFrac(big.NewInt(int64(m)), minorScale(c)).FloatString(c.MinorUnits())
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	return json.Number(s)
+}
+
+func minorScale(c Currency) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.MinorUnits())), nil)
+}
+
+func (m Money) Format(c Currency) string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	exp := c.MinorUnits()
+	if exp == 0 {
+		return fmt.Sprintf("%s%d %s", sign, v, c)
+	}
+
+	div := int64(1)
+	for i := 0; i < exp; i++ {
+		div *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, v/div, exp, v%div, c)
+}
+
+// Currency is an ISO 4217 code. It is kept exactly as received, so orders in
+// unknown currencies still round-trip; Valid tells whether it is one we know.
+type Currency string
+
+const (
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+	CurrencyRUB Currency = "RUB"
+	CurrencyBYN Currency = "BYN"
+	CurrencyKZT Currency = "KZT"
+	CurrencyKGS Currency = "KGS"
+	CurrencyAMD Currency = "AMD"
+	CurrencyUZS Currency = "UZS"
+	CurrencyGEL Currency = "GEL"
+	CurrencyCNY Currency = "CNY"
+	CurrencyTRY Currency = "TRY"
+)
+
+var minorUnits = map[Currency]int{
+	CurrencyUSD: 2,
+	CurrencyEUR: 2,
+	CurrencyRUB: 2,
+	CurrencyBYN: 2,
+	CurrencyKZT: 2,
+	CurrencyKGS: 2,
+	CurrencyAMD: 2,
+	CurrencyUZS: 2,
+	CurrencyGEL: 2,
+	CurrencyCNY: 2,
+	CurrencyTRY: 2,
+}
+
+// Valid matches codes case-insensitively, as some producers send "rub".
+func (c Currency) Valid() bool {
+	_, ok := minorUnits[c.code()]
+	return ok
+}
+
+func (c Currency) MinorUnits() int {
+	return minorUnits[c.code()]
+}
+
+func (c Currency) code() Currency {
+	return Currency(strings.ToUpper(string(c)))
+}
@@ -1,18 +1,97 @@
 package model
 
+import (
+	"encoding/json"
+	"fmt"
+	"l0/internal/domain"
+	"time"
+)
+
 type Order struct {
-	OrderUID          string   `json:"order_uid"`
-	TrackNumber       string   `json:"track_number"`
-	Entry             string   `json:"entry"`
-	Delivery          Delivery `json:"delivery"`
-	Payment           Payment  `json:"payment"`
-	Items             []Item   `json:"items"`
-	Locale            string   `json:"locale"`
-	InternalSignature string   `json:"internal_signature"`
-	CustomerID        string   `json:"customer_id"`
-	DeliveryService   string   `json:"delivery_service"`
-	ShardKey          string   `json:"shardkey"`
-	SMID              int      `json:"sm_id"`
-	DateCreated       string   `json:"date_created"`
-	OOFShard          string   `json:"oof_shard"`
+	OrderUID          string      `json:"order_uid"`
+	TrackNumber       string      `json:"track_number"`
+	Entry             string      `json:"entry"`
+	Delivery          Delivery    `json:"delivery"`
+	Payment           Payment     `json:"payment"`
+	Items             []Item      `json:"items"`
+	Locale            string      `json:"locale"`
+	InternalSignature string      `json:"internal_signature"`
+	CustomerID        string      `json:"customer_id"`
+	DeliveryService   string      `json:"delivery_service"`
+	ShardKey          string      `json:"shardkey"`
+	SMID              int         `json:"sm_id"`
+	DateCreated       time.Time   `json:"date_created"`
+	OOFShard          string      `json:"oof_shard"`
+	Status            OrderStatus `json:"status,omitempty"`
+}
+
+// Item prices travel in major units of the payment currency, like the
+// payment amounts; Payment converts its own.
+type orderJSON struct {
+	order
+	Items []itemJSON `json:"items"`
+}
+
+type order Order
+
+type itemJSON struct {
+	item
+	Price      json.Number `json:"price"`
+	TotalPrice json.Number `json:"total_price"`
+}
+
+type item Item
+
+func (o Order) MarshalJSON() ([]byte, error) {
+	v := orderJSON{order: order(o)}
+	if o.Items != nil {
+		v.Items = make([]itemJSON, len(o.Items))
+	}
+	for i, it := range o.Items {
+		v.Items[i] = itemJSON{
+			item:       item(it),
+			Price:      it.Price.wire(o.Payment.Currency),
+			TotalPrice: it.TotalPrice.wire(o.Payment.Currency),
+		}
+	}
+	return json.Marshal(v)
+}
+
+func (o *Order) UnmarshalJSON(data []byte) error {
+	var v orderJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*o = Order(v.order)
+	o.Items = nil
+	if v.Items != nil {
+		o.Items = make([]Item, len(v.Items))
+	}
+	for i, it := range v.Items {
+		o.Items[i] = Item(it.item)
+
+		var err error
+		if o.Items[i].Price, err = parseAmount(it.Price, o.Payment.Currency); err != nil {
+			return fmt.Errorf("item %d price: %w", i, err)
+		}
+		if o.Items[i].TotalPrice, err = parseAmount(it.TotalPrice, o.Payment.Currency); err != nil {
+			return fmt.Errorf("item %d total_price: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks what decoding deliberately accepts as it comes: the payment
+// currency and the item statuses.
+func (o Order) Validate() error {
+	if !o.Payment.Currency.Valid() {
+		return domain.Errorf(domain.ErrInvalid, "order %s: unknown currency %q", o.OrderUID, o.Payment.Currency)
+	}
+	for i, item := range o.Items {
+		if !item.Status.Valid() {
+			return domain.Errorf(domain.ErrInvalid, "order %s: item %d: invalid status %d", o.OrderUID, i, item.Status)
+		}
+	}
+	return nil
 }
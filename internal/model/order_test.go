@@ -0,0 +1,117 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"l0/internal/domain"
+	"l0/internal/lib/utils"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderWireFormat(t *testing.T) {
+	var order model.Order
+	require.NoError(t, json.Unmarshal([]byte(utils.TestOrder), &order))
+
+	assert.Equal(t, model.CurrencyUSD, order.Payment.Currency)
+	assert.Equal(t, model.Money(181700), order.Payment.Amount, "the feed sends whole dollars")
+	assert.Equal(t, model.Money(45300), order.Items[0].Price)
+	assert.Equal(t, time.Unix(1637907727, 0).UTC(), order.Payment.PaymentDT)
+	assert.Equal(t, time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC), order.DateCreated)
+	assert.Equal(t, model.ItemStatusAccepted, order.Items[0].Status)
+
+	data, err := json.Marshal(order)
+	require.NoError(t, err)
+	assert.JSONEq(t, utils.TestOrder, string(data))
+}
+
+func TestPaymentZeroTime(t *testing.T) {
+	data, err := json.Marshal(model.Payment{})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"payment_dt":0`)
+
+	var p model.Payment
+	require.NoError(t, json.Unmarshal(data, &p))
+	assert.True(t, p.PaymentDT.IsZero())
+}
+
+func TestOrderZeroRoundTrip(t *testing.T) {
+	for _, c := range []model.Currency{"", "rub", "XXX"} {
+		t.Run(string(c), func(t *testing.T) {
+			order := model.Order{Payment: model.Payment{Currency: c}}
+
+			data, err := json.Marshal(order)
+			require.NoError(t, err)
+
+			var got model.Order
+			require.NoError(t, json.Unmarshal(data, &got))
+			assert.Equal(t, order, got)
+		})
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	assert.True(t, model.CurrencyRUB.Valid())
+	assert.True(t, model.Currency("rub").Valid())
+	assert.Equal(t, 2, model.Currency("rub").MinorUnits())
+	assert.False(t, model.Currency("XXX").Valid())
+	assert.False(t, model.Currency("").Valid())
+}
+
+func TestOrderValidate(t *testing.T) {
+	var order model.Order
+	require.NoError(t, json.Unmarshal([]byte(utils.TestOrder), &order))
+	assert.NoError(t, order.Validate())
+
+	order.Payment.Currency = "XXX"
+	err := order.Validate()
+	assert.ErrorIs(t, err, domain.ErrInvalid)
+	assert.EqualError(t, err, `order b563feb7b2b84best: unknown currency "XXX"`)
+
+	order.Payment.Currency = model.CurrencyUSD
+	order.Items[0].Status = 0
+	assert.ErrorIs(t, order.Validate(), domain.ErrInvalid)
+}
+
+func TestMoneyFormat(t *testing.T) {
+	assert.Equal(t, "18.17 USD", model.Money(1817).Format(model.CurrencyUSD))
+	assert.Equal(t, "-0.05 RUB", model.Money(-5).Format(model.CurrencyRUB))
+}
+
+func TestMoneyWire(t *testing.T) {
+	tests := []struct {
+		currency model.Currency
+		wire     string
+		money    model.Money
+	}{
+		{model.CurrencyUSD, `18.17`, 1817},
+		{model.CurrencyUSD, `18.1`, 1810},
+		{model.CurrencyRUB, `-0.05`, -5},
+		{model.CurrencyRUB, `1800`, 180000},
+		{"XXX", `1800`, 1800},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.wire, func(t *testing.T) {
+			data := `{"payment":{"currency":"` + string(tc.currency) + `","amount":` + tc.wire + `},"items":[{"price":` + tc.wire + `}]}`
+
+			var order model.Order
+			require.NoError(t, json.Unmarshal([]byte(data), &order))
+			assert.Equal(t, tc.money, order.Payment.Amount)
+			assert.Equal(t, tc.money, order.Items[0].Price)
+
+			out, err := json.Marshal(order)
+			require.NoError(t, err)
+			assert.Contains(t, string(out), `"amount":`+tc.wire+`,`)
+			assert.Contains(t, string(out), `"price":`+tc.wire+`,`)
+		})
+	}
+
+	var order model.Order
+	assert.Error(t, json.Unmarshal([]byte(`{"payment":{"currency":"USD","amount":18.171}}`), &order), "more decimals than cents")
+	assert.Error(t, json.Unmarshal([]byte(`{"payment":{"currency":"USD"},"items":[{"price":0.001}]}`), &order))
+}
@@ -1,14 +1,79 @@
 package model
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type Payment struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDT    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	Transaction  string    `json:"transaction"`
+	RequestID    string    `json:"request_id"`
+	Currency     Currency  `json:"currency"`
+	Provider     string    `json:"provider"`
+	Amount       Money     `json:"amount"`
+	PaymentDT    time.Time `json:"payment_dt"`
+	Bank         string    `json:"bank"`
+	DeliveryCost Money     `json:"delivery_cost"`
+	GoodsTotal   Money     `json:"goods_total"`
+	CustomFee    Money     `json:"custom_fee"`
+}
+
+// payment_dt travels as unix seconds on the wire, amounts in major units of
+// the currency.
+type paymentJSON struct {
+	payment
+	PaymentDT    int64       `json:"payment_dt"`
+	Amount       json.Number `json:"amount"`
+	DeliveryCost json.Number `json:"delivery_cost"`
+	GoodsTotal   json.Number `json:"goods_total"`
+	CustomFee    json.Number `json:"custom_fee"`
+}
+
+type payment Payment
+
+func (p Payment) MarshalJSON() ([]byte, error) {
+	var dt int64
+	if !p.PaymentDT.IsZero() {
+		dt = p.PaymentDT.Unix()
+	}
+	return json.Marshal(paymentJSON{
+		payment:      payment(p),
+		PaymentDT:    dt,
+		Amount:       p.Amount.wire(p.Currency),
+		DeliveryCost: p.DeliveryCost.wire(p.Currency),
+		GoodsTotal:   p.GoodsTotal.wire(p.Currency),
+		CustomFee:    p.CustomFee.wire(p.Currency),
+	})
+}
+
+func (p *Payment) UnmarshalJSON(data []byte) error {
+	var v paymentJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*p = Payment(v.payment)
+	p.PaymentDT = time.Time{}
+	if v.PaymentDT != 0 {
+		p.PaymentDT = time.Unix(v.PaymentDT, 0).UTC()
+	}
+
+	for _, a := range []struct {
+		dst   *Money
+		value json.Number
+		name  string
+	}{
+		{&p.Amount, v.Amount, "amount"},
+		{&p.DeliveryCost, v.DeliveryCost, "delivery_cost"},
+		{&p.GoodsTotal, v.GoodsTotal, "goods_total"},
+		{&p.CustomFee, v.CustomFee, "custom_fee"},
+	} {
+		m, err := parseAmount(a.value, p.Currency)
+		if err != nil {
+			return fmt.Errorf("payment %s: %w", a.name, err)
+		}
+		*a.dst = m
+	}
+	return nil
 }
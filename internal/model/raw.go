@@ -0,0 +1,15 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type RawOrder struct {
+	OrderUID    string          `json:"order_uid"`
+	Subject     string          `json:"subject"`
+	Sequence    uint64          `json:"sequence"`
+	PublishedAt time.Time       `json:"published_at"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
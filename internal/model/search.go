@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type SearchResult struct {
+	OrderUID    string    `json:"order_uid"`
+	TrackNumber string    `json:"track_number"`
+	CustomerID  string    `json:"customer_id"`
+	DateCreated time.Time `json:"date_created"`
+	Rank        float64   `json:"rank"`
+	// Headline is an HTML snippet of the matching text: the text is escaped
+	// and matches are wrapped in <mark>.
+	Headline string `json:"headline"`
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"fmt"
+	"l0/internal/domain"
+	"strings"
+	"time"
+)
+
+// OrderStatus is where an order is in its lifecycle. The feed never sets
+// it: orders start as created and move on only through status changes.
+type OrderStatus string
+
+const (
+	StatusCreated    OrderStatus = "created"
+	StatusPaid       OrderStatus = "paid"
+	StatusAssembling OrderStatus = "assembling"
+	StatusShipped    OrderStatus = "shipped"
+	StatusDelivered  OrderStatus = "delivered"
+	StatusCancelled  OrderStatus = "cancelled"
+	StatusReturned   OrderStatus = "returned"
+)
+
+// transitions lists the statuses each status may change to. Orders can be
+// cancelled until they are shipped and returned once they are; cancelled and
+// returned are final.
+var transitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:    {StatusPaid, StatusCancelled},
+	StatusPaid:       {StatusAssembling, StatusCancelled},
+	StatusAssembling: {StatusShipped, StatusCancelled},
+	StatusShipped:    {StatusDelivered, StatusReturned},
+	StatusDelivered:  {StatusReturned},
+	StatusCancelled:  {},
+	StatusReturned:   {},
+}
+
+var ErrUnknownStatus = fmt.Errorf("unknown order status: %w", domain.ErrInvalid)
+
+// TransitionError rejects a status change the lifecycle does not allow. It
+// is a domain.ErrConflict.
+type TransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *TransitionError) Error() string {
+	next := e.From.Next()
+	if len(next) == 0 {
+		return fmt.Sprintf("order status cannot change from %s: it is final", e.From)
+	}
+
+	allowed := make([]string, len(next))
+	for i, s := range next {
+		allowed[i] = string(s)
+	}
+	return fmt.Sprintf("order status cannot change from %s to %s, only to %s", e.From, e.To, strings.Join(allowed, ", "))
+}
+
+func (e *TransitionError) Unwrap() error {
+	return domain.ErrConflict
+}
+
+func (e *TransitionError) Detail() string {
+	return e.Error()
+}
+
+func (s OrderStatus) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// Next returns the statuses s may change to.
+func (s OrderStatus) Next() []OrderStatus {
+	return transitions[s]
+}
+
+// Transition checks that an order in status s may change to to.
+func (s OrderStatus) Transition(to OrderStatus) error {
+	if !to.Valid() {
+		return domain.Errorf(ErrUnknownStatus, "unknown order status %q", to)
+	}
+	for _, next := range transitions[s] {
+		if next == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: s, To: to}
+}
+
+type StatusChange struct {
+	OrderUID  string      `json:"order_uid"`
+	From      OrderStatus `json:"from"`
+	To        OrderStatus `json:"to"`
+	Reason    string      `json:"reason,omitempty"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
@@ -0,0 +1,27 @@
+package model_test
+
+import (
+	"testing"
+
+	"l0/internal/domain"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	assert.NoError(t, model.StatusCreated.Transition(model.StatusPaid))
+	assert.NoError(t, model.StatusAssembling.Transition(model.StatusCancelled))
+	assert.NoError(t, model.StatusDelivered.Transition(model.StatusReturned))
+
+	err := model.StatusShipped.Transition(model.StatusPaid)
+	var terr *model.TransitionError
+	assert.ErrorAs(t, err, &terr)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.EqualError(t, err, "order status cannot change from shipped to paid, only to delivered, returned")
+
+	assert.EqualError(t, model.StatusCancelled.Transition(model.StatusPaid), "order status cannot change from cancelled: it is final")
+	assert.ErrorIs(t, model.StatusCreated.Transition("lost"), model.ErrUnknownStatus)
+	assert.ErrorIs(t, model.StatusCreated.Transition("lost"), domain.ErrInvalid)
+	assert.False(t, model.OrderStatus("").Valid())
+}
@@ -2,7 +2,6 @@ package repository
 
 import (
 	"database/sql"
-	"fmt"
 	"l0/internal/model"
 )
 
@@ -13,7 +12,18 @@ func (s *Storage) AddDelivery(tx *sql.Tx, delivery model.Delivery) (int64, error
 	query := "INSERT INTO delivery (name, phone, zip, city, address, region, email) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id"
 	err := tx.QueryRow(query, delivery.Name, delivery.Phone, delivery.Zip, delivery.City, delivery.Address, delivery.Region, delivery.Email).Scan(&id)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return 0, wrap(op, err)
 	}
 	return id, nil
 }
+
+func (s *Storage) UpdateDelivery(tx *sql.Tx, orderUID string, delivery model.Delivery) error {
+	const op = "storage.postgres.UpdateDelivery"
+
+	query := "UPDATE delivery SET name = $2, phone = $3, zip = $4, city = $5, address = $6, region = $7, email = $8 WHERE id = (SELECT delivery_id FROM orders WHERE order_uid = $1)"
+	_, err := tx.Exec(query, orderUID, delivery.Name, delivery.Phone, delivery.Zip, delivery.City, delivery.Address, delivery.Region, delivery.Email)
+	if err != nil {
+		return wrap(op, err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"l0/internal/model"
+	"strings"
+	"time"
+)
+
+var erasedPaths = []string{"delivery.name", "delivery.phone", "delivery.zip", "delivery.address", "delivery.email"}
+
+func (s *Storage) EraseCustomerData(req model.ErasureRequest) (_ model.ErasureRecord, err error) {
+	const op = "storage.postgres.EraseCustomerData"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return model.ErasureRecord{}, wrap(op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			err = wrap(op, err)
+		}
+	}()
+
+	uids, err := s.anonymize(tx, req)
+	if err != nil {
+		return model.ErasureRecord{}, wrap(op, err)
+	}
+
+	record, err := s.appendErasureLog(tx, req, uids)
+	if err != nil {
+		return model.ErasureRecord{}, wrap(op, err)
+	}
+
+	return record, nil
+}
+
+// AnonymizeCustomerData is the first half of EraseCustomerData, for callers
+// that keep the erasure log in another database.
+func (s *Storage) AnonymizeCustomerData(req model.ErasureRequest) (_ []string, err error) {
+	const op = "storage.postgres.AnonymizeCustomerData"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			err = wrap(op, err)
+		}
+	}()
+
+	uids, err := s.anonymize(tx, req)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return uids, nil
+}
+
+func (s *Storage) AppendErasureLog(req model.ErasureRequest, uids []string) (_ model.ErasureRecord, err error) {
+	const op = "storage.postgres.AppendErasureLog"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return model.ErasureRecord{}, wrap(op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			err = wrap(op, err)
+		}
+	}()
+
+	record, err := s.appendErasureLog(tx, req, uids)
+	if err != nil {
+		return model.ErasureRecord{}, wrap(op, err)
+	}
+
+	return record, nil
+}
+
+func (s *Storage) anonymize(tx *sql.Tx, req model.ErasureRequest) ([]string, error) {
+	var lookup string
+	switch req.SubjectType {
+	case model.SubjectCustomer:
+		lookup = "SELECT order_uid FROM orders WHERE customer_id = $1 ORDER BY order_uid" + s.forUpdate()
+	case model.SubjectOrder:
+		lookup = "SELECT order_uid FROM orders WHERE order_uid = $1" + s.forUpdate()
+	default:
+		return nil, fmt.Errorf("unknown subject type %q", req.SubjectType)
+	}
+
+	uids, err := queryStrings(tx, lookup, req.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	src := model.ChangeSource{Kind: req.Source, Ref: req.RequestedBy}
+	if src.Kind == "" {
+		src.Kind = model.SourceAdmin
+	}
+	for _, uid := range uids {
+		if err := s.eraseOrder(tx, uid, src); err != nil {
+			return nil, fmt.Errorf("order %s: %w", uid, err)
+		}
+	}
+
+	return uids, nil
+}
+
+func (s *Storage) eraseOrder(tx *sql.Tx, uid string, src model.ChangeSource) error {
+	prev, err := s.lastVersion(tx, uid)
+	if err != nil {
+		return err
+	}
+	if prev == nil {
+		return nil
+	}
+
+	next := *prev
+	next.Delivery = prev.Delivery.Anonymized()
+
+	if err := s.UpdateDelivery(tx, uid, next.Delivery); err != nil {
+		return err
+	}
+
+	if err := touchOrder(tx, uid); err != nil {
+		return err
+	}
+
+	if err := s.AddOrderVersion(tx, prev, next, src); err != nil {
+		return err
+	}
+
+	return s.scrubHistory(tx, uid, next.Delivery)
+}
+
+// scrubHistory rewrites every stored version and raw message of the order so
+// that neither the snapshots, the diffs nor the original payloads keep the
+// erased values.
+func (s *Storage) scrubHistory(tx *sql.Tx, uid string, delivery model.Delivery) error {
+	patch, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	if s.driver == DriverSQLite {
+		return s.scrubHistorySQLite(tx, uid, patch)
+	}
+
+	query := `
+		UPDATE order_history SET
+			snapshot = jsonb_set(snapshot, '{delivery}', COALESCE(snapshot->'delivery', '{}'::jsonb) || $2::jsonb),
+			diff = (
+				SELECT COALESCE(jsonb_agg(
+					CASE WHEN c->>'path' = ANY(string_to_array($3, ',')) THEN jsonb_build_object('path', c->>'path') ELSE c END
+				), '[]'::jsonb)
+				FROM jsonb_array_elements(diff) c
+			)
+		WHERE order_uid = $1`
+
+	_, err = tx.Exec(query, uid, string(patch), strings.Join(erasedPaths, ","))
+	if err != nil {
+		return err
+	}
+
+	rawQuery := `
+		UPDATE order_raw_messages SET payload = jsonb_set(payload, '{delivery}', payload->'delivery' || $2::jsonb)
+		WHERE order_uid = $1 AND jsonb_typeof(payload->'delivery') = 'object'`
+
+	_, err = tx.Exec(rawQuery, uid, string(patch))
+	return err
+}
+
+func (s *Storage) appendErasureLog(tx *sql.Tx, req model.ErasureRequest, uids []string) (model.ErasureRecord, error) {
+	// SQLite transactions already hold the database write lock.
+	if s.driver != DriverSQLite {
+		if _, err := tx.Exec("LOCK TABLE erasure_log IN EXCLUSIVE MODE"); err != nil {
+			return model.ErasureRecord{}, err
+		}
+	}
+
+	record := model.ErasureRecord{
+		SubjectType: req.SubjectType,
+		SubjectID:   req.SubjectID,
+		OrderUIDs:   uids,
+		RequestedBy: req.RequestedBy,
+		ErasedAt:    time.Now().UTC().Truncate(time.Microsecond),
+		PrevHash:    model.ErasureGenesisHash,
+	}
+	if record.OrderUIDs == nil {
+		record.OrderUIDs = []string{}
+	}
+
+	err := tx.QueryRow("SELECT hash FROM erasure_log ORDER BY id DESC LIMIT 1").Scan(&record.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.ErasureRecord{}, err
+	}
+	record.Hash = record.ComputeHash()
+
+	uidsJSON, err := json.Marshal(record.OrderUIDs)
+	if err != nil {
+		return model.ErasureRecord{}, err
+	}
+
+	query := `INSERT INTO erasure_log (subject_type, subject_id, order_uids, requested_by, erased_at, prev_hash, hash)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	err = tx.QueryRow(query, record.SubjectType, record.SubjectID, string(uidsJSON), record.RequestedBy, record.ErasedAt, record.PrevHash, record.Hash).Scan(&record.ID)
+	if err != nil {
+		return model.ErasureRecord{}, err
+	}
+
+	return record, nil
+}
+
+func (s *Storage) GetErasureLog() ([]model.ErasureRecord, error) {
+	const op = "storage.postgres.GetErasureLog"
+
+	rows, err := s.db.Query("SELECT id, subject_type, subject_id, order_uids, requested_by, erased_at, prev_hash, hash FROM erasure_log ORDER BY id")
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	defer rows.Close()
+
+	var records []model.ErasureRecord
+	for rows.Next() {
+		var r model.ErasureRecord
+		var uidsJSON []byte
+
+		if err := rows.Scan(&r.ID, &r.SubjectType, &r.SubjectID, &uidsJSON, &r.RequestedBy, &r.ErasedAt, &r.PrevHash, &r.Hash); err != nil {
+			return nil, wrap(op, err)
+		}
+
+		if err := json.Unmarshal(uidsJSON, &r.OrderUIDs); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse order_uids JSON: %w", op, err)
+		}
+
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return records, nil
+}
+
+func queryStrings(tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanStrings(rows)
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+
+	return out, rows.Err()
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"l0/internal/domain"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// wrap prefixes err with the failed operation and marks the errors of a
+// database that cannot be reached as domain.ErrUnavailable.
+func wrap(op string, err error) error {
+	if unreachable(err) {
+		err = domain.Unavailable(err)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func unreachable(err error) bool {
+	var netErr net.Error
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return true
+	case errors.As(err, &pqErr):
+		// Connection exceptions and operator intervention, e.g. a shutdown.
+		class := pqErr.Code.Class()
+		return class == "08" || class == "57"
+	}
+	return false
+}
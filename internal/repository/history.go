@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"l0/internal/lib/diff"
+	"l0/internal/model"
+)
+
+func (s *Storage) AddOrderVersion(tx *sql.Tx, prev *model.Order, next model.Order, src model.ChangeSource) error {
+	const op = "storage.postgres.AddOrderVersion"
+
+	changes, err := diff.Compare(prev, next)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	snapshot, err := json.Marshal(next)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	query := `INSERT INTO order_history (order_uid, version, source, source_ref, diff, snapshot)
+			  SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5 FROM order_history WHERE order_uid = $1`
+
+	_, err = tx.Exec(query, next.OrderUID, src.Kind, src.Ref, string(changesJSON), string(snapshot))
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetOrderHistory(id string) ([]model.OrderVersion, error) {
+	const op = "storage.postgres.GetOrderHistory"
+
+	query := `
+		SELECT order_uid, version, source, source_ref, changed_at, diff, snapshot
+		FROM order_history
+		WHERE order_uid = $1
+		ORDER BY version`
+
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	defer rows.Close()
+
+	history := []model.OrderVersion{}
+	for rows.Next() {
+		var v model.OrderVersion
+		var changesJSON, snapshot []byte
+
+		if err := rows.Scan(&v.OrderUID, &v.Version, &v.Source.Kind, &v.Source.Ref, &v.ChangedAt, &changesJSON, &snapshot); err != nil {
+			return nil, wrap(op, err)
+		}
+		v.Snapshot = snapshot
+
+		if err := json.Unmarshal(changesJSON, &v.Diff); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse diff JSON: %w", op, err)
+		}
+
+		history = append(history, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return history, nil
+}
+
+// lastVersion returns the order as of its latest recorded version, falling
+// back to the current rows for orders written before history existed.
+func (s *Storage) lastVersion(tx *sql.Tx, id string) (*model.Order, error) {
+	var snapshot []byte
+	err := tx.QueryRow("SELECT snapshot FROM order_history WHERE order_uid = $1 ORDER BY version DESC LIMIT 1", id).Scan(&snapshot)
+	if err == nil {
+		var order model.Order
+		if err := json.Unmarshal(snapshot, &order); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot JSON: %w", err)
+		}
+		return &order, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	order, err := scanOrder(tx.QueryRow(s.selectOrder()+" WHERE o.order_uid = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// Like snapshots, the comparison leaves the status out.
+	order.Status = ""
+	return &order, nil
+}
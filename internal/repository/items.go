@@ -2,7 +2,6 @@ package repository
 
 import (
 	"database/sql"
-	"fmt"
 	"l0/internal/model"
 )
 
@@ -13,9 +12,20 @@ func (s *Storage) AddItems(tx *sql.Tx, order_uid string, items []model.Item) err
 	for _, item := range items {
 		_, err := tx.Exec(query, order_uid, item.ChrtID, item.TrackNumber, item.Price, item.RID, item.Name, item.Sale, item.Size, item.TotalPrice, item.NMID, item.Brand, item.Status)
 		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
+			return wrap(op, err)
 		}
 	}
 
 	return nil
 }
+
+func (s *Storage) DeleteItems(tx *sql.Tx, order_uid string) error {
+	const op = "storage.postgres.DeleteItems"
+
+	_, err := tx.Exec("DELETE FROM items WHERE order_uid = $1", order_uid)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
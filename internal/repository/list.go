@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"l0/internal/domain"
+	"l0/internal/model"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = domain.Errorf(domain.ErrInvalid, "invalid cursor")
+
+// OrderFilter selects the orders of a listing. Empty fields match every
+// order; orders created in [From, To) are kept, zero bounds being open.
+// Brand matches orders with at least one item of that brand.
+type OrderFilter struct {
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	Brand           string
+	From            time.Time
+	To              time.Time
+}
+
+// OrderPage is a keyset page of a listing: up to Limit orders after the
+// cursor, newest first or, with Oldest, oldest first.
+type OrderPage struct {
+	After  OrderCursor
+	Oldest bool
+	Limit  int
+}
+
+// Token encodes the cursor for clients, which pass it back unchanged.
+func (c OrderCursor) Token() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func ParseCursor(token string) (OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+
+	var c OrderCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.IsZero() {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// conditions renders the filter as SQL conditions on orders o, numbering
+// placeholders after the ones already in args.
+func (f OrderFilter) conditions(args []any) ([]string, []any) {
+	var conds []string
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.CustomerID != "" {
+		add("o.customer_id = $%d", f.CustomerID)
+	}
+	if f.TrackNumber != "" {
+		add("o.track_number = $%d", f.TrackNumber)
+	}
+	if f.DeliveryService != "" {
+		add("o.delivery_service = $%d", f.DeliveryService)
+	}
+	if f.Brand != "" {
+		add("EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.brand = $%d)", f.Brand)
+	}
+	if !f.From.IsZero() {
+		add("o.date_created >= $%d", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		add("o.date_created < $%d", f.To.UTC())
+	}
+	return conds, args
+}
+
+// Matches reports whether the listing keeps o, like conditions does in SQL.
+func (f OrderFilter) Matches(o model.Order) bool {
+	switch {
+	case f.CustomerID != "" && o.CustomerID != f.CustomerID,
+		f.TrackNumber != "" && o.TrackNumber != f.TrackNumber,
+		f.DeliveryService != "" && o.DeliveryService != f.DeliveryService,
+		!f.From.IsZero() && o.DateCreated.Before(f.From),
+		!f.To.IsZero() && !o.DateCreated.Before(f.To):
+		return false
+	}
+	if f.Brand == "" {
+		return true
+	}
+	return slices.ContainsFunc(o.Items, func(it model.Item) bool { return it.Brand == f.Brand })
+}
+
+// Follows reports whether o comes after the cursor of the page.
+func (p OrderPage) Follows(o model.Order) bool {
+	if p.After.IsZero() {
+		return true
+	}
+	at := model.Order{DateCreated: p.After.DateCreated, OrderUID: p.After.OrderUID}
+	if p.Oldest {
+		return newerThan(o, at)
+	}
+	return newerThan(at, o)
+}
+
+// Sort puts orders in the order of the page.
+func (p OrderPage) Sort(orders []model.Order) {
+	slices.SortFunc(orders, func(a, b model.Order) int {
+		if p.Oldest {
+			a, b = b, a
+		}
+		switch {
+		case newerThan(a, b):
+			return -1
+		case newerThan(b, a):
+			return 1
+		}
+		return 0
+	})
+}
+
+func where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// ListOrders returns a page of the orders matching f. Like the order stream,
+// pages are keyset-based on (date_created, order_uid).
+func (s *Storage) ListOrders(ctx context.Context, f OrderFilter, p OrderPage) ([]model.Order, error) {
+	const op = "storage.postgres.ListOrders"
+
+	conds, args := f.conditions(nil)
+
+	cmp, dir := "<", "DESC"
+	if p.Oldest {
+		cmp, dir = ">", "ASC"
+	}
+	if !p.After.IsZero() {
+		args = append(args, p.After.DateCreated.UTC(), p.After.OrderUID)
+		conds = append(conds, fmt.Sprintf("(o.date_created %[1]s $%[2]d OR (o.date_created = $%[2]d AND o.order_uid %[1]s $%[3]d))",
+			cmp, len(args)-1, len(args)))
+	}
+	args = append(args, p.Limit)
+
+	query := s.selectOrder() + where(conds) +
+		fmt.Sprintf(" ORDER BY o.date_created %[1]s, o.order_uid %[1]s LIMIT $%[2]d", dir, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	defer rows.Close()
+
+	orders := make([]model.Order, 0, p.Limit)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, wrap(op, err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return orders, nil
+}
+
+func (s *Storage) CountOrdersMatching(ctx context.Context, f OrderFilter) (int, error) {
+	const op = "storage.postgres.CountOrdersMatching"
+
+	conds, args := f.conditions(nil)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders o"+where(conds), args...).Scan(&total); err != nil {
+		return 0, wrap(op, err)
+	}
+	return total, nil
+}
+
+func (s *ShardedStorage) ListOrders(ctx context.Context, f OrderFilter, p OrderPage) ([]model.Order, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]model.Order, error) {
+		return shard.ListOrders(ctx, f, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.ListOrders: %w", err)
+	}
+
+	less := newerThan
+	if p.Oldest {
+		less = func(a, b model.Order) bool { return newerThan(b, a) }
+	}
+	return page(mergeSorted(results, less), p.Limit, 0), nil
+}
+
+func (s *ShardedStorage) CountOrdersMatching(ctx context.Context, f OrderFilter) (int, error) {
+	counts, err := fanOut(s, func(shard *Storage) (int, error) {
+		return shard.CountOrdersMatching(ctx, f)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("storage.sharded.CountOrdersMatching: %w", err)
+	}
+
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	return total, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"l0/migrations"
+	"sync"
+
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// goose keeps the dialect and the migrations FS in package globals.
+var gooseMu sync.Mutex
+
+func useMigrations(driver string) error {
+	switch driver {
+	case DriverPostgres:
+		goose.SetBaseFS(migrations.FS)
+		return goose.SetDialect("postgres")
+	case DriverSQLite:
+		sub, err := fs.Sub(migrations.SQLiteFS, "sqlite")
+		if err != nil {
+			return err
+		}
+		goose.SetBaseFS(sub)
+		return goose.SetDialect("sqlite3")
+	default:
+		return fmt.Errorf("unknown database driver %q", driver)
+	}
+}
+
+// Migrate runs a goose command (up, down, status, redo, version, ...)
+// against the migrations compiled into the binary.
+func Migrate(db *sql.DB, driver, command string, args ...string) error {
+	gooseMu.Lock()
+	defer gooseMu.Unlock()
+
+	if err := useMigrations(driver); err != nil {
+		return err
+	}
+	if err := goose.Run(command, db, ".", args...); err != nil {
+		return fmt.Errorf("goose %s: %w", command, err)
+	}
+	return nil
+}
+
+func ExpectedSchemaVersion(driver string) (int64, error) {
+	gooseMu.Lock()
+	defer gooseMu.Unlock()
+
+	return expectedSchemaVersion(driver)
+}
+
+func expectedSchemaVersion(driver string) (int64, error) {
+	if err := useMigrations(driver); err != nil {
+		return 0, err
+	}
+
+	all, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := all.Last()
+	if err != nil {
+		return 0, err
+	}
+	return last.Version, nil
+}
+
+// CheckSchemaVersion fails when the database is not at exactly the version
+// this binary was built for. It only reads: applying migrations is left to
+// the migrate command.
+func CheckSchemaVersion(db *sql.DB, driver string) error {
+	gooseMu.Lock()
+	defer gooseMu.Unlock()
+
+	expected, err := expectedSchemaVersion(driver)
+	if err != nil {
+		return fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	lookup := "SELECT to_regclass($1)::text"
+	if driver == DriverSQLite {
+		lookup = "SELECT name FROM sqlite_master WHERE type = 'table' AND name = $1"
+	}
+
+	var current int64
+	var table sql.NullString
+	err = db.QueryRow(lookup, goose.TableName()).Scan(&table)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to look up migrations table: %w", err)
+	}
+	if table.Valid {
+		current, err = goose.GetDBVersion(db)
+		if err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+	}
+
+	if current != expected {
+		return fmt.Errorf("schema version is %d, binary expects %d: run `migrate up`", current, expected)
+	}
+
+	return nil
+}
@@ -6,16 +6,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"l0/internal/domain"
+	"l0/internal/lib/diff"
 	"l0/internal/model"
 	"log"
+	"time"
 )
 
-func (s *Storage) AddOrder(ordr model.Order) error {
+func (s *Storage) AddOrder(ordr model.Order, src model.ChangeSource) error {
 	var err error
 	const op = "storage.postgres.AddOrder"
+
+	// The status is not the feed's to set; versions only track feed content.
+	ordr.Status = ""
+
 	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return wrap(op, err)
 	}
 
 	defer func() {
@@ -28,83 +35,210 @@ func (s *Storage) AddOrder(ordr model.Order) error {
 		}
 	}()
 
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM orders WHERE order_uid = $1"+s.forUpdate()+")", ordr.OrderUID).Scan(&exists)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	if !exists {
+		err = s.insertOrder(tx, ordr)
+		if err != nil {
+			return wrap(op, err)
+		}
+
+		err = s.AddOrderVersion(tx, nil, ordr, src)
+		if err != nil {
+			return wrap(op, err)
+		}
+
+		err = s.addRawFromSource(tx, src)
+		if err != nil {
+			return wrap(op, err)
+		}
+		return nil
+	}
+
+	err = s.addRawFromSource(tx, src)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	prev, err := s.lastVersion(tx, ordr.OrderUID)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	changes, err := diff.Compare(prev, ordr)
+	if err != nil {
+		return wrap(op, err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	err = s.updateOrder(tx, ordr)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	err = s.AddOrderVersion(tx, prev, ordr, src)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) addRawFromSource(tx *sql.Tx, src model.ChangeSource) error {
+	if src.Raw == nil {
+		return nil
+	}
+	return s.AddRawOrder(tx, *src.Raw)
+}
+
+func (s *Storage) insertOrder(tx *sql.Tx, ordr model.Order) error {
 	idDvr, err := s.AddDelivery(tx, ordr.Delivery)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
 	idPymnt, err := s.AddPayment(tx, ordr.Payment)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
-	query := `INSERT INTO orders (order_uid, track_number, entry, delivery_id, payment_id, locale, internal_signature, customer_id, delivery_service, shardkey, sm_id, oof_shard)
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	status := ordr.Status
+	if status == "" {
+		status = model.StatusCreated
+	}
+
+	query := `INSERT INTO orders (order_uid, track_number, entry, delivery_id, payment_id, locale, internal_signature, customer_id, delivery_service, shardkey, sm_id, oof_shard, date_created, status, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE($13, CURRENT_TIMESTAMP), $14, $15)`
 
-	_, err = tx.Exec(query, ordr.OrderUID, ordr.TrackNumber, ordr.Entry, idDvr, idPymnt, ordr.Locale, ordr.InternalSignature, ordr.CustomerID, ordr.DeliveryService, ordr.ShardKey, ordr.SMID, ordr.OOFShard)
+	_, err = tx.Exec(query, ordr.OrderUID, ordr.TrackNumber, ordr.Entry, idDvr, idPymnt, ordr.Locale, ordr.InternalSignature, ordr.CustomerID, ordr.DeliveryService, ordr.ShardKey, ordr.SMID, ordr.OOFShard, dateCreated(ordr), status, time.Now().UTC())
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
-	err = s.AddItems(tx, ordr.OrderUID, ordr.Items)
+	if err := s.AddItems(tx, ordr.OrderUID, ordr.Items); err != nil {
+		return err
+	}
+
+	return s.RefreshSearch(tx, ordr.OrderUID)
+}
+
+func (s *Storage) updateOrder(tx *sql.Tx, ordr model.Order) error {
+	if err := s.UpdateDelivery(tx, ordr.OrderUID, ordr.Delivery); err != nil {
+		return err
+	}
+
+	if err := s.UpdatePayment(tx, ordr.OrderUID, ordr.Payment); err != nil {
+		return err
+	}
+
+	query := `UPDATE orders SET track_number = $2, entry = $3, locale = $4, internal_signature = $5, customer_id = $6,
+				  delivery_service = $7, shardkey = $8, sm_id = $9, oof_shard = $10, date_created = COALESCE($11, date_created), updated_at = $12
+			  WHERE order_uid = $1`
+
+	_, err := tx.Exec(query, ordr.OrderUID, ordr.TrackNumber, ordr.Entry, ordr.Locale, ordr.InternalSignature, ordr.CustomerID, ordr.DeliveryService, ordr.ShardKey, ordr.SMID, ordr.OOFShard, dateCreated(ordr), time.Now().UTC())
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
-	return nil
+	if err := s.DeleteItems(tx, ordr.OrderUID); err != nil {
+		return err
+	}
+
+	if err := s.AddItems(tx, ordr.OrderUID, ordr.Items); err != nil {
+		return err
+	}
+
+	return s.RefreshSearch(tx, ordr.OrderUID)
 }
 
-func (s *Storage) GetOrderById(id string) (model.Order, error) {
-	const op = "storage.postgres.GetOrderById"
+// touchOrder marks the order as changed by a write that does not go through
+// updateOrder.
+func touchOrder(tx *sql.Tx, id string) error {
+	_, err := tx.Exec("UPDATE orders SET updated_at = $2 WHERE order_uid = $1", id, time.Now().UTC())
+	return err
+}
+
+func dateCreated(ordr model.Order) *time.Time {
+	if ordr.DateCreated.IsZero() {
+		return nil
+	}
+	// SQLite compares timestamps as text, which only sorts in a single zone.
+	t := ordr.DateCreated.UTC()
+	return &t
+}
+
+const selectOrderQuery = `
+	SELECT o.order_uid, o.track_number, o.entry, 
+		d.name, d.phone, d.zip, d.city, d.address, d.region, d.email, 
+		p."transaction", p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
+		p.bank, p.delivery_cost, p.goods_total, p.custom_fee, 
+		o.locale, o.internal_signature, o.customer_id, o.delivery_service, 
+		o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.status,
+		COALESCE(i.items, '[]'::json) AS items
+	FROM orders o
+	JOIN delivery d ON o.delivery_id = d.id
+	JOIN payment p ON o.payment_id = p.id
+	LEFT JOIN LATERAL (
+		SELECT json_agg(i) AS items
+		FROM items i
+		WHERE i.order_uid = o.order_uid
+	) i ON true`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
+// scanOrder reads a row of selectOrder. Columns selected after the order's
+// own go to extra.
+func scanOrder(row rowScanner, extra ...any) (model.Order, error) {
 	var order model.Order
 	var delivery model.Delivery
 	var payment model.Payment
-	var itemsJSON json.RawMessage
+	var itemsJSON []byte
 
-	query := `
-		SELECT o.order_uid, o.track_number, o.entry, 
-			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email, 
-			p.transaction, p.request_id, p.currency, p.provider, p.amount, 
-			p.bank, p.delivery_cost, p.goods_total, p.custom_fee, 
-			o.locale, o.internal_signature, o.customer_id, o.delivery_service, 
-			o.shardkey, o.sm_id, o.date_created, o.oof_shard,
-			COALESCE(i.items, '[]'::json) AS items
-		FROM orders o
-		JOIN delivery d ON o.delivery_id = d.id
-		JOIN payment p ON o.payment_id = p.id
-		LEFT JOIN LATERAL (
-			SELECT json_agg(i) AS items
-			FROM items i
-			WHERE i.order_uid = o.order_uid
-		) i ON true
-		WHERE o.order_uid = $1
-	`
-
-	err := s.db.QueryRow(query, id).Scan(
+	dest := []any{
 		&order.OrderUID,
 		&order.TrackNumber,
 		&order.Entry,
 		&delivery.Name, &delivery.Phone, &delivery.Zip, &delivery.City, &delivery.Address, &delivery.Region, &delivery.Email,
-		&payment.Transaction, &payment.RequestID, &payment.Currency, &payment.Provider, &payment.Amount,
+		&payment.Transaction, &payment.RequestID, &payment.Currency, &payment.Provider, &payment.Amount, &payment.PaymentDT,
 		&payment.Bank, &payment.DeliveryCost, &payment.GoodsTotal, &payment.CustomFee,
 		&order.Locale, &order.InternalSignature, &order.CustomerID, &order.DeliveryService,
-		&order.ShardKey, &order.SMID, &order.DateCreated, &order.OOFShard,
+		&order.ShardKey, &order.SMID, &order.DateCreated, &order.OOFShard, &order.Status,
 		&itemsJSON,
-	)
-
+	}
+	err := row.Scan(append(dest, extra...)...)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return model.Order{}, fmt.Errorf("%s: order with id %s not found", op, id)
-		}
-		return model.Order{}, fmt.Errorf("%s: %w", op, err)
+		return model.Order{}, err
 	}
 
+	order.DateCreated = order.DateCreated.UTC()
+	payment.PaymentDT = payment.PaymentDT.UTC()
 	order.Delivery = delivery
 	order.Payment = payment
 
 	if err := json.Unmarshal(itemsJSON, &order.Items); err != nil {
-		return model.Order{}, fmt.Errorf("%s: failed to parse items JSON: %w", op, err)
+		return model.Order{}, fmt.Errorf("failed to parse items JSON: %w", err)
+	}
+
+	return order, nil
+}
+
+func (s *Storage) GetOrderById(id string) (model.Order, error) {
+	const op = "storage.postgres.GetOrderById"
+
+	order, err := scanOrder(s.db.QueryRow(s.selectOrder()+" WHERE o.order_uid = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Order{}, fmt.Errorf("%s: %w", op, domain.Errorf(domain.ErrOrderNotFound, "order %s not found", id))
+		}
+		return model.Order{}, wrap(op, err)
 	}
 
 	return order, nil
@@ -115,57 +249,18 @@ func (s *Storage) GetAllOrders(limit, offset int) ([]model.Order, error) {
 
 	var orders []model.Order
 
-	query := `
-		SELECT o.order_uid, o.track_number, o.entry, 
-			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email, 
-			p.transaction, p.request_id, p.currency, p.provider, p.amount, 
-			p.bank, p.delivery_cost, p.goods_total, p.custom_fee, 
-			o.locale, o.internal_signature, o.customer_id, o.delivery_service, 
-			o.shardkey, o.sm_id, o.date_created, o.oof_shard,
-			COALESCE(i.items, '[]'::json) AS items
-		FROM orders o
-		JOIN delivery d ON o.delivery_id = d.id
-		JOIN payment p ON o.payment_id = p.id
-		LEFT JOIN LATERAL (
-			SELECT json_agg(i) AS items
-			FROM items i
-			WHERE i.order_uid = o.order_uid
-		) i ON true
-		LIMIT $1 OFFSET $2
-	`
-
-	rows, err := s.db.Query(query, limit, offset)
+	rows, err := s.db.Query(s.selectOrder()+" ORDER BY o.order_uid LIMIT $1 OFFSET $2", limit, offset)
 	fmt.Print("LIMIT", limit, "OFFSET", offset)
 
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, wrap(op, err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var order model.Order
-		var delivery model.Delivery
-		var payment model.Payment
-		var itemsJSON json.RawMessage
-
-		if err := rows.Scan(
-			&order.OrderUID,
-			&order.TrackNumber,
-			&order.Entry,
-			&delivery.Name, &delivery.Phone, &delivery.Zip, &delivery.City, &delivery.Address, &delivery.Region, &delivery.Email,
-			&payment.Transaction, &payment.RequestID, &payment.Currency, &payment.Provider, &payment.Amount,
-			&payment.Bank, &payment.DeliveryCost, &payment.GoodsTotal, &payment.CustomFee,
-			&order.Locale, &order.InternalSignature, &order.CustomerID, &order.DeliveryService,
-			&order.ShardKey, &order.SMID, &order.DateCreated, &order.OOFShard,
-			&itemsJSON,
-		); err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
-		}
-
-		order.Delivery = delivery
-		order.Payment = payment
-
-		if err := json.Unmarshal(itemsJSON, &order.Items); err != nil {
-			return nil, fmt.Errorf("%s: failed to parse items JSON: %w", op, err)
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, wrap(op, err)
 		}
 
 		orders = append(orders, order)
@@ -186,9 +281,9 @@ func (s *Storage) GetOrdersBatch(ctx context.Context, batchSize int, processBatc
 	for offset := 0; offset < total; offset += batchSize {
 		query := `
 			SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
-				   o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+				   o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.status,
 				   d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
-				   p.transaction, p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
+				   p."transaction", p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
 				   p.bank, p.delivery_cost, p.goods_total, p.custom_fee
 			FROM orders o
 			JOIN delivery d ON o.delivery_id = d.id
@@ -210,7 +305,7 @@ func (s *Storage) GetOrdersBatch(ctx context.Context, batchSize int, processBatc
 
 			err := rows.Scan(
 				&o.OrderUID, &o.TrackNumber, &o.Entry, &o.Locale, &o.InternalSignature,
-				&o.CustomerID, &o.DeliveryService, &o.ShardKey, &o.SMID, &o.DateCreated, &o.OOFShard,
+				&o.CustomerID, &o.DeliveryService, &o.ShardKey, &o.SMID, &o.DateCreated, &o.OOFShard, &o.Status,
 				&d.Name, &d.Phone, &d.Zip, &d.City, &d.Address, &d.Region, &d.Email,
 				&p.Transaction, &p.RequestID, &p.Currency, &p.Provider, &p.Amount, &p.PaymentDT,
 				&p.Bank, &p.DeliveryCost, &p.GoodsTotal, &p.CustomFee,
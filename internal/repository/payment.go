@@ -2,18 +2,38 @@ package repository
 
 import (
 	"database/sql"
-	"fmt"
 	"l0/internal/model"
+	"time"
 )
 
 func (s *Storage) AddPayment(tx *sql.Tx, payment model.Payment) (int64, error) {
 	const op = "storage.postgres.AddPayment"
 
 	var id int64
-	query := "INSERT INTO payment (transaction, request_id, currency, provider, amount, bank, delivery_cost, goods_total, custom_fee) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id"
-	err := tx.QueryRow(query, payment.Transaction, payment.RequestID, payment.Currency, payment.Provider, payment.Amount, payment.Bank, payment.DeliveryCost, payment.GoodsTotal, payment.CustomFee).Scan(&id)
+	query := "INSERT INTO payment (\"transaction\", request_id, currency, provider, amount, payment_dt, bank, delivery_cost, goods_total, custom_fee) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id"
+	err := tx.QueryRow(query, payment.Transaction, payment.RequestID, payment.Currency, payment.Provider, payment.Amount, paymentTime(payment), payment.Bank, payment.DeliveryCost, payment.GoodsTotal, payment.CustomFee).Scan(&id)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return 0, wrap(op, err)
 	}
 	return id, nil
 }
+
+func (s *Storage) UpdatePayment(tx *sql.Tx, orderUID string, payment model.Payment) error {
+	const op = "storage.postgres.UpdatePayment"
+
+	query := "UPDATE payment SET \"transaction\" = $2, request_id = $3, currency = $4, provider = $5, amount = $6, payment_dt = $7, bank = $8, delivery_cost = $9, goods_total = $10, custom_fee = $11 WHERE id = (SELECT payment_id FROM orders WHERE order_uid = $1)"
+	_, err := tx.Exec(query, orderUID, payment.Transaction, payment.RequestID, payment.Currency, payment.Provider, payment.Amount, paymentTime(payment), payment.Bank, payment.DeliveryCost, payment.GoodsTotal, payment.CustomFee)
+	if err != nil {
+		return wrap(op, err)
+	}
+	return nil
+}
+
+// paymentTime maps a missing payment_dt to the epoch, which is what the
+// column defaults to and what the wire format sends for "no payment yet".
+func paymentTime(payment model.Payment) time.Time {
+	if payment.PaymentDT.IsZero() {
+		return time.Unix(0, 0).UTC()
+	}
+	return payment.PaymentDT
+}
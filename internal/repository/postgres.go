@@ -7,56 +7,113 @@ import (
 	"l0/internal/config"
 	"log"
 	"os"
-	"path/filepath"
 	"time"
 
 	_ "github.com/lib/pq"
-	"github.com/pressly/goose"
 )
 
 type Storage struct {
-	db *sql.DB
+	db     *sql.DB
+	driver string
+}
+
+func New(cfg *config.Config) (Repository, error) {
+	if cfg.Database.Driver == DriverSQLite {
+		storage, err := ConnectSQLite(cfg.StoragePath)
+		if err != nil {
+			return nil, err
+		}
+		return storage, nil
+	}
+
+	dbs := Databases(cfg)
+	if len(dbs) == 1 {
+		storage, err := Connect(dbs[0])
+		if err != nil {
+			return nil, err
+		}
+		return storage, nil
+	}
+
+	shardMap, err := NewShardMap(cfg.Sharding, len(dbs))
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]*Storage, 0, len(dbs))
+	for i, db := range dbs {
+		storage, err := Connect(db)
+		if err != nil {
+			for _, s := range shards {
+				s.Close()
+			}
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		shards = append(shards, storage)
+	}
+
+	return NewSharded(shards, shardMap), nil
+}
+
+// Databases lists every configured Postgres database, the primary one first.
+// DB_* environment variables override the primary database only.
+func Databases(cfg *config.Config) []config.Database {
+	primary := config.Database{
+		Driver:   cfg.Database.Driver,
+		Host:     getEnv("DB_HOST", cfg.Database.Host),
+		Port:     getEnv("DB_PORT", cfg.Database.Port),
+		User:     getEnv("DB_USER", cfg.Database.User),
+		Password: getEnv("DB_PASSWORD", cfg.Database.Password),
+		Dbname:   getEnv("DB_NAME", cfg.Database.Dbname),
+	}
+
+	return append([]config.Database{primary}, cfg.Sharding.Shards...)
 }
 
 func Connect(cfg config.Database) (*Storage, error) {
 	const op = "storage.postgre.New"
 
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	if err := CheckSchemaVersion(db, DriverPostgres); err != nil {
+		db.Close()
+		return nil, wrap(op, err)
+	}
+
+	return &Storage{db: db, driver: DriverPostgres}, nil
+}
+
+func Open(cfg config.Database) (*sql.DB, error) {
 	sqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		getEnv("DB_HOST", cfg.Host),
-		getEnv("DB_PORT", cfg.Port),
-		getEnv("DB_USER", cfg.User),
-		getEnv("DB_PASSWORD", cfg.Password),
-		getEnv("DB_NAME", cfg.Dbname),
+		cfg.Host,
+		cfg.Port,
+		cfg.User,
+		cfg.Password,
+		cfg.Dbname,
 	)
 
 	log.Printf("Attempting to connect to database with")
 
 	db, err := sql.Open("postgres", sqlInfo)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := db.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("%s: failed to ping database: %w", op, err)
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	if err := goose.Up(db, "migrations"); err != nil {
-		return nil, fmt.Errorf("%s: goose up: %w", op, err)
-	}
-
-	migrationsDir, err := filepath.Abs("./migrations")
-	if err != nil {
-		return nil, fmt.Errorf("%s: failed to get migrations path: %w", op, err)
-	}
-
-	log.Printf("Applying migrations from: %s", migrationsDir)
-	if err := goose.Up(db, migrationsDir); err != nil {
-		return nil, fmt.Errorf("%s: failed to apply migrations: %w", op, err)
-	}
+	return db, nil
+}
 
-	return &Storage{db: db}, nil
+func (s *Storage) Close() error {
+	return s.db.Close()
 }
 
 func getEnv(key, defaultValue string) string {
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"l0/internal/domain"
+	"l0/internal/model"
+)
+
+func (s *Storage) AddRawOrder(tx *sql.Tx, raw model.RawOrder) error {
+	const op = "storage.postgres.AddRawOrder"
+
+	query := `INSERT INTO order_raw_messages (order_uid, subject, sequence, published_at, payload)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (subject, sequence) DO NOTHING`
+
+	_, err := tx.Exec(query, raw.OrderUID, raw.Subject, raw.Sequence, raw.PublishedAt, string(raw.Payload))
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetRawOrder(id string) (model.RawOrder, error) {
+	const op = "storage.postgres.GetRawOrder"
+
+	query := `
+		SELECT order_uid, subject, sequence, published_at, received_at, payload
+		FROM order_raw_messages
+		WHERE order_uid = $1
+		ORDER BY id DESC
+		LIMIT 1`
+
+	var raw model.RawOrder
+	var payload []byte
+	err := s.db.QueryRow(query, id).Scan(&raw.OrderUID, &raw.Subject, &raw.Sequence, &raw.PublishedAt, &raw.ReceivedAt, &payload)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.RawOrder{}, fmt.Errorf("%s: %w", op, domain.Errorf(domain.ErrOrderNotFound, "no raw message for order %s", id))
+		}
+		return model.RawOrder{}, wrap(op, err)
+	}
+	raw.Payload = payload
+
+	return raw, nil
+}
+
+func (s *Storage) GetRawOrders(id string) ([]model.RawOrder, error) {
+	const op = "storage.postgres.GetRawOrders"
+
+	query := `
+		SELECT order_uid, subject, sequence, published_at, received_at, payload
+		FROM order_raw_messages
+		WHERE order_uid = $1
+		ORDER BY id`
+
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	defer rows.Close()
+
+	var raws []model.RawOrder
+	for rows.Next() {
+		var raw model.RawOrder
+		var payload []byte
+		if err := rows.Scan(&raw.OrderUID, &raw.Subject, &raw.Sequence, &raw.PublishedAt, &raw.ReceivedAt, &payload); err != nil {
+			return nil, wrap(op, err)
+		}
+		raw.Payload = payload
+		raws = append(raws, raw)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return raws, nil
+}
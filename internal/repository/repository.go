@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"l0/internal/model"
+)
+
+// Repository is what the services need from order storage. It is satisfied
+// by a single Postgres database (Storage) and by ShardedStorage.
+type Repository interface {
+	AddOrder(ordr model.Order, src model.ChangeSource) error
+	GetOrderById(id string) (model.Order, error)
+	GetAllOrders(limit, offset int) ([]model.Order, error)
+	GetOrdersBatch(ctx context.Context, batchSize int, processBatch func([]model.Order) error) error
+	GetOrdersNewestFirst(ctx context.Context, after OrderCursor, limit int) ([]model.Order, error)
+	SampleOrdersNewestFirst(ctx context.Context, after OrderCursor, limit int, fraction float64) ([]model.Order, error)
+	GetOrdersChangedSince(ctx context.Context, after ChangeCursor, limit int) ([]ChangedOrder, error)
+	CountOrders(ctx context.Context) (int, error)
+	ListOrders(ctx context.Context, f OrderFilter, p OrderPage) ([]model.Order, error)
+	CountOrdersMatching(ctx context.Context, f OrderFilter) (int, error)
+	ExistingOrderIDs(ctx context.Context, ids []string) ([]string, error)
+	GetOrderHistory(id string) ([]model.OrderVersion, error)
+	GetRawOrder(id string) (model.RawOrder, error)
+	SetOrderStatus(ctx context.Context, id string, to model.OrderStatus, reason string) (model.StatusChange, error)
+	GetStatusHistory(ctx context.Context, id string) ([]model.StatusChange, error)
+	SearchOrders(query string, limit, offset int) ([]model.SearchResult, error)
+	EraseCustomerData(req model.ErasureRequest) (model.ErasureRecord, error)
+	GetErasureLog() ([]model.ErasureRecord, error)
+	Close() error
+}
+
+var (
+	_ Repository = (*Storage)(nil)
+	_ Repository = (*ShardedStorage)(nil)
+)
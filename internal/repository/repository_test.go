@@ -0,0 +1,436 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"l0/internal/config"
+	"l0/internal/domain"
+	"l0/internal/lib/utils"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// forEachBackend runs fn against a freshly migrated Storage of every backend
+// available here. SQLite always runs; Postgres runs when TEST_DB_HOST is set
+// and points at a scratch database the test may reset.
+func forEachBackend(t *testing.T, fn func(t *testing.T, s *Storage)) {
+	t.Run(DriverSQLite, func(t *testing.T) {
+		fn(t, sqliteStorage(t))
+	})
+
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		return
+	}
+
+	t.Run(DriverPostgres, func(t *testing.T) {
+		cfg := config.Database{
+			Host:     host,
+			Port:     getEnv("TEST_DB_PORT", "5432"),
+			User:     getEnv("TEST_DB_USER", "postgres"),
+			Password: getEnv("TEST_DB_PASSWORD", "postgres"),
+			Dbname:   getEnv("TEST_DB_NAME", "l0_test"),
+		}
+
+		db, err := Open(cfg)
+		require.NoError(t, err)
+		require.NoError(t, Migrate(db, DriverPostgres, "reset"))
+		require.NoError(t, Migrate(db, DriverPostgres, "up"))
+		db.Close()
+
+		s, err := Connect(cfg)
+		require.NoError(t, err)
+		defer s.Close()
+
+		fn(t, s)
+	})
+}
+
+// sqliteStorage returns a freshly migrated SQLite Storage that is closed when
+// the test ends.
+func sqliteStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "l0.db")
+
+	db, err := OpenSQLite(path)
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db, DriverSQLite, "up"))
+	db.Close()
+
+	s, err := ConnectSQLite(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func testOrder(t *testing.T) model.Order {
+	t.Helper()
+
+	var order model.Order
+	require.NoError(t, json.Unmarshal([]byte(utils.TestOrder), &order))
+	return order
+}
+
+var natsSource = model.ChangeSource{Kind: model.SourceNATS, Ref: "1"}
+
+func TestStorageOrderRoundTrip(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		order := testOrder(t)
+		require.NoError(t, s.AddOrder(order, natsSource))
+		order.Status = model.StatusCreated
+
+		got, err := s.GetOrderById(order.OrderUID)
+		require.NoError(t, err)
+		assert.Equal(t, order, got)
+
+		all, err := s.GetAllOrders(10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Order{order}, all)
+
+		_, err = s.GetOrderById("missing")
+		assert.True(t, errors.Is(err, domain.ErrOrderNotFound))
+	})
+}
+
+func TestStorageMoneyMigration(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		order := testOrder(t)
+		require.NoError(t, s.AddOrder(order, natsSource))
+		order.Status = model.StatusCreated
+
+		// Before the migration amounts were stored in whole units.
+		require.NoError(t, Migrate(s.db, s.driver, "down-to", "20250722100000"))
+		var amount int64
+		require.NoError(t, s.db.QueryRow("SELECT amount FROM payment").Scan(&amount))
+		assert.Equal(t, int64(1817), amount)
+
+		require.NoError(t, Migrate(s.db, s.driver, "up"))
+		got, err := s.GetOrderById(order.OrderUID)
+		require.NoError(t, err)
+		assert.Equal(t, order, got)
+	})
+}
+
+func TestStorageHistory(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		order := testOrder(t)
+		require.NoError(t, s.AddOrder(order, natsSource))
+		// Redelivery of the same payload must not create a version.
+		require.NoError(t, s.AddOrder(order, natsSource))
+
+		order.Delivery.City = "Haifa"
+		require.NoError(t, s.AddOrder(order, model.ChangeSource{Kind: model.SourceNATS, Ref: "2"}))
+
+		history, err := s.GetOrderHistory(order.OrderUID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, 2, history[1].Version)
+		require.Len(t, history[1].Diff, 1)
+		assert.Equal(t, "delivery.city", history[1].Diff[0].Path)
+		assert.Equal(t, "Haifa", history[1].Diff[0].New)
+
+		got, err := s.GetOrderById(order.OrderUID)
+		require.NoError(t, err)
+		assert.Equal(t, "Haifa", got.Delivery.City)
+	})
+}
+
+func TestStorageRawOrder(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		order := testOrder(t)
+		src := natsSource
+		src.Raw = &model.RawOrder{OrderUID: order.OrderUID, Subject: "orders", Sequence: 1, Payload: json.RawMessage(utils.TestOrder)}
+		require.NoError(t, s.AddOrder(order, src))
+
+		raw, err := s.GetRawOrder(order.OrderUID)
+		require.NoError(t, err)
+		assert.Equal(t, order.OrderUID, raw.OrderUID)
+		assert.JSONEq(t, utils.TestOrder, string(raw.Payload))
+	})
+}
+
+func TestStorageSearch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		order := testOrder(t)
+		order.Items[0].Name = `<img src=x onerror=alert(1)> Mascaras`
+		require.NoError(t, s.AddOrder(order, natsSource))
+
+		for _, query := range []string{"Mascaras", "Vivienne", "Kiryat"} {
+			results, err := s.SearchOrders(query, 10, 0)
+			require.NoError(t, err)
+			require.Len(t, results, 1, query)
+			assert.Equal(t, order.OrderUID, results[0].OrderUID)
+		}
+
+		results, err := s.SearchOrders("Mascaras", 10, 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Contains(t, results[0].Headline, "<mark>Mascaras</mark>")
+		assert.Contains(t, results[0].Headline, "&lt;img")
+		assert.NotContains(t, results[0].Headline, "<img")
+
+		results, err = s.SearchOrders("nothing", 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestStorageErasure(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		order := testOrder(t)
+		require.NoError(t, s.AddOrder(order, natsSource))
+
+		record, err := s.EraseCustomerData(model.ErasureRequest{
+			SubjectType: model.SubjectCustomer,
+			SubjectID:   order.CustomerID,
+			RequestedBy: "test",
+			Source:      model.SourceHTTP,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{order.OrderUID}, record.OrderUIDs)
+
+		got, err := s.GetOrderById(order.OrderUID)
+		require.NoError(t, err)
+		assert.Equal(t, order.Delivery.Anonymized(), got.Delivery)
+
+		history, err := s.GetOrderHistory(order.OrderUID)
+		require.NoError(t, err)
+		assert.NotContains(t, string(history[0].Snapshot), order.Delivery.Email)
+		require.Len(t, history, 2)
+		assert.Equal(t, model.ChangeSource{Kind: model.SourceHTTP, Ref: "test"}, history[1].Source)
+
+		records, err := s.GetErasureLog()
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, model.ErasureGenesisHash, records[0].PrevHash)
+		assert.Equal(t, records[0].ComputeHash(), records[0].Hash)
+	})
+}
+
+func TestStorageOrdersNewestFirst(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		base := testOrder(t)
+		created := base.DateCreated
+		for i, uid := range []string{"b", "a", "c"} {
+			order := base
+			order.OrderUID = uid
+			order.Payment.Transaction = uid
+			order.DateCreated = created.Add(time.Duration(i%2) * time.Hour)
+			require.NoError(t, s.AddOrder(order, natsSource))
+		}
+
+		total, err := s.CountOrders(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+
+		var uids []string
+		var cursor OrderCursor
+		for {
+			page, err := s.GetOrdersNewestFirst(context.Background(), cursor, 2)
+			require.NoError(t, err)
+			if len(page) == 0 {
+				break
+			}
+			for _, o := range page {
+				uids = append(uids, o.OrderUID)
+			}
+			cursor = CursorAt(page[len(page)-1])
+		}
+
+		// "a" is an hour newer; "c" and "b" tie on date_created.
+		assert.Equal(t, []string{"a", "c", "b"}, uids)
+
+		existing, err := s.ExistingOrderIDs(context.Background(), []string{"a", "x", "c"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a", "c"}, existing)
+	})
+}
+
+func TestStorageSampleOrders(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		base := testOrder(t)
+		const total = 40
+		for i := range total {
+			order := base
+			order.OrderUID = fmt.Sprintf("o%02d", i)
+			order.Payment.Transaction = order.OrderUID
+			order.DateCreated = base.DateCreated.Add(time.Duration(i) * time.Minute)
+			require.NoError(t, s.AddOrder(order, natsSource))
+		}
+
+		sample := func(fraction float64) []string {
+			var uids []string
+			var cursor OrderCursor
+			for {
+				page, err := s.SampleOrdersNewestFirst(context.Background(), cursor, 5, fraction)
+				require.NoError(t, err)
+				if len(page) == 0 {
+					return uids
+				}
+				for _, o := range page {
+					uids = append(uids, o.OrderUID)
+				}
+				cursor = CursorAt(page[len(page)-1])
+			}
+		}
+
+		assert.Len(t, sample(1), total)
+
+		// Dropping all or none of 40 orders at one half is practically
+		// impossible.
+		half := sample(0.5)
+		assert.NotEmpty(t, half)
+		assert.Less(t, len(half), total)
+		assert.IsNonIncreasing(t, half, "the sample keeps the stream order")
+	})
+}
+
+func TestStorageOrdersChangedSince(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		base := testOrder(t)
+		for _, uid := range []string{"a", "b", "c"} {
+			order := base
+			order.OrderUID = uid
+			order.Payment.Transaction = uid
+			require.NoError(t, s.AddOrder(order, natsSource))
+		}
+
+		changed := func(after ChangeCursor) []string {
+			var uids []string
+			for {
+				page, err := s.GetOrdersChangedSince(ctx, after, 2)
+				require.NoError(t, err)
+				if len(page) == 0 {
+					return uids
+				}
+				for _, c := range page {
+					uids = append(uids, c.Order.OrderUID)
+				}
+				after = page[len(page)-1].Cursor()
+			}
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, changed(ChangeCursor{}))
+
+		mark := time.Now().UTC()
+		time.Sleep(10 * time.Millisecond)
+		_, err := s.SetOrderStatus(ctx, "a", model.StatusPaid, "")
+		require.NoError(t, err)
+
+		page, err := s.GetOrdersChangedSince(ctx, ChangeCursor{UpdatedAt: mark}, 10)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, "a", page[0].Order.OrderUID)
+		assert.Equal(t, model.StatusPaid, page[0].Order.Status)
+		assert.True(t, page[0].UpdatedAt.After(mark))
+	})
+}
+
+func TestStorageListOrders(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		base := testOrder(t)
+		created := base.DateCreated
+		for i, uid := range []string{"a", "b", "c", "d"} {
+			order := base
+			order.OrderUID = uid
+			order.Payment.Transaction = uid
+			order.DateCreated = created.Add(time.Duration(i) * time.Hour)
+			order.Items = append([]model.Item(nil), base.Items...)
+			if uid == "c" {
+				order.CustomerID = "other"
+				order.Items[0].Brand = "Acme"
+			}
+			require.NoError(t, s.AddOrder(order, natsSource))
+		}
+
+		list := func(f OrderFilter, oldest bool) []string {
+			var uids []string
+			p := OrderPage{Oldest: oldest, Limit: 2}
+			for {
+				orders, err := s.ListOrders(ctx, f, p)
+				require.NoError(t, err)
+				if len(orders) == 0 {
+					return uids
+				}
+				for _, o := range orders {
+					uids = append(uids, o.OrderUID)
+				}
+				p.After = CursorAt(orders[len(orders)-1])
+			}
+		}
+
+		assert.Equal(t, []string{"d", "c", "b", "a"}, list(OrderFilter{}, false))
+		assert.Equal(t, []string{"a", "b", "c", "d"}, list(OrderFilter{}, true))
+		assert.Equal(t, []string{"d", "b", "a"}, list(OrderFilter{CustomerID: base.CustomerID}, false))
+		assert.Equal(t, []string{"c"}, list(OrderFilter{Brand: "Acme"}, false))
+		assert.Equal(t, []string{"c", "b"}, list(OrderFilter{From: created.Add(time.Hour), To: created.Add(3 * time.Hour)}, false))
+		assert.Empty(t, list(OrderFilter{DeliveryService: "nobody"}, false))
+
+		total, err := s.CountOrdersMatching(ctx, OrderFilter{CustomerID: base.CustomerID, From: created.Add(time.Hour)})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+
+		cursor, err := ParseCursor(CursorAt(base).Token())
+		require.NoError(t, err)
+		assert.Equal(t, base.OrderUID, cursor.OrderUID)
+		_, err = ParseCursor("not a cursor")
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestStorageOrderStatus(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *Storage) {
+		ctx := context.Background()
+		order := testOrder(t)
+		require.NoError(t, s.AddOrder(order, natsSource))
+
+		change, err := s.SetOrderStatus(ctx, order.OrderUID, model.StatusPaid, "card")
+		require.NoError(t, err)
+		assert.Equal(t, model.StatusCreated, change.From)
+		assert.Equal(t, model.StatusPaid, change.To)
+
+		_, err = s.SetOrderStatus(ctx, order.OrderUID, model.StatusDelivered, "")
+		var terr *model.TransitionError
+		assert.ErrorAs(t, err, &terr)
+		_, err = s.SetOrderStatus(ctx, order.OrderUID, "lost", "")
+		assert.ErrorIs(t, err, model.ErrUnknownStatus)
+		_, err = s.SetOrderStatus(ctx, "missing", model.StatusPaid, "")
+		assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+
+		// A feed update neither resets the status nor records it as a change.
+		order.TrackNumber = "NEWTRACK"
+		require.NoError(t, s.AddOrder(order, natsSource))
+		got, err := s.GetOrderById(order.OrderUID)
+		require.NoError(t, err)
+		assert.Equal(t, model.StatusPaid, got.Status)
+
+		versions, err := s.GetOrderHistory(order.OrderUID)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.Len(t, versions[1].Diff, 1)
+		assert.Equal(t, "track_number", versions[1].Diff[0].Path)
+
+		_, err = s.SetOrderStatus(ctx, order.OrderUID, model.StatusCancelled, "customer request")
+		require.NoError(t, err)
+
+		history, err := s.GetStatusHistory(ctx, order.OrderUID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, model.StatusPaid, history[1].From)
+		assert.Equal(t, model.StatusCancelled, history[1].To)
+		assert.Equal(t, "customer request", history[1].Reason)
+		assert.False(t, history[1].ChangedAt.IsZero())
+	})
+}
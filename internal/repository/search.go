@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"html"
+	"l0/internal/model"
+	"strings"
+)
+
+// Headlines come from feed data and may hold any markup. The database wraps
+// matches in these control characters instead of <mark>, so that the text
+// can be escaped before the tags are put in.
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+var headlineTags = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// headline turns a snippet into HTML that is safe to render: the text is
+// escaped and only the <mark> tags around matches are markup.
+func headline(snippet string) string {
+	return headlineTags.Replace(html.EscapeString(snippet))
+}
+
+func (s *Storage) RefreshSearch(tx *sql.Tx, order_uid string) error {
+	const op = "storage.postgres.RefreshSearch"
+
+	if s.driver == DriverSQLite {
+		if err := s.refreshSearchSQLite(tx, order_uid); err != nil {
+			return wrap(op, err)
+		}
+		return nil
+	}
+
+	if _, err := tx.Exec("SELECT refresh_order_search($1)", order_uid); err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SearchOrders(query string, limit, offset int) ([]model.SearchResult, error) {
+	const op = "storage.postgres.SearchOrders"
+
+	sqlQuery := `
+		SELECT o.order_uid, o.track_number, o.customer_id, o.date_created,
+			ts_rank(o.search_vector, q) AS rank,
+			ts_headline('simple', o.search_document, q, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=3') AS headline
+		FROM orders o, websearch_to_tsquery('simple', $1) q
+		WHERE o.search_vector @@ q
+		ORDER BY rank DESC, o.date_created DESC, o.order_uid
+		LIMIT $2 OFFSET $3`
+
+	var rows *sql.Rows
+	var err error
+	if s.driver == DriverSQLite {
+		rows, err = s.searchOrdersSQLite(query, limit, offset)
+	} else {
+		rows, err = s.db.Query(sqlQuery, query, limit, offset)
+	}
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	defer rows.Close()
+
+	results := []model.SearchResult{}
+	for rows.Next() {
+		var r model.SearchResult
+		if err := rows.Scan(&r.OrderUID, &r.TrackNumber, &r.CustomerID, &r.DateCreated, &r.Rank, &r.Headline); err != nil {
+			return nil, wrap(op, err)
+		}
+		r.Headline = headline(r.Headline)
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return results, nil
+}
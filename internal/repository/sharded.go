@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"l0/internal/domain"
+	"l0/internal/model"
+	"sort"
+	"sync"
+)
+
+// ShardedStorage spreads orders over several Postgres databases by shardkey.
+// New orders go to the shard the map picks; reads by order_uid ask every
+// shard, since the uid alone does not say where an order lives. Shard 0 also
+// keeps the erasure log.
+type ShardedStorage struct {
+	shards   []*Storage
+	shardMap ShardMap
+}
+
+func NewSharded(shards []*Storage, shardMap ShardMap) *ShardedStorage {
+	return &ShardedStorage{
+		shards:   shards,
+		shardMap: shardMap,
+	}
+}
+
+// AddOrder updates an order on the shard that already holds it, next to its
+// history. If the new shardkey maps elsewhere, the order is then moved there
+// the way Rebalance would, so there is never a second copy to read instead.
+func (s *ShardedStorage) AddOrder(ordr model.Order, src model.ChangeSource) error {
+	const op = "storage.sharded.AddOrder"
+
+	holders, err := fanOut(s, func(shard *Storage) (bool, error) {
+		return shard.HasOrder(ordr.OrderUID)
+	})
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	// A copy on another shard is the one updates went to; a copy on the
+	// mapped shard as well is left by an interrupted move and is replaced.
+	to := s.shardMap.Shard(ordr.ShardKey)
+	from := -1
+	for i, held := range holders {
+		if held && i != to {
+			from = i
+			break
+		}
+	}
+	if from == -1 {
+		return s.shards[to].AddOrder(ordr, src)
+	}
+
+	if err := s.shards[from].AddOrder(ordr, src); err != nil {
+		return err
+	}
+	if err := s.move(ordr.OrderUID, s.shards[from], s.shards[to]); err != nil {
+		return fmt.Errorf("%s: move from shard %d to %d: %w", op, from, to, err)
+	}
+	return nil
+}
+
+func (s *ShardedStorage) GetOrderById(id string) (model.Order, error) {
+	return findFirst(s, func(shard *Storage) (model.Order, error) {
+		return shard.GetOrderById(id)
+	})
+}
+
+func (s *ShardedStorage) GetRawOrder(id string) (model.RawOrder, error) {
+	return findFirst(s, func(shard *Storage) (model.RawOrder, error) {
+		return shard.GetRawOrder(id)
+	})
+}
+
+func (s *ShardedStorage) GetOrderHistory(id string) ([]model.OrderVersion, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]model.OrderVersion, error) {
+		return shard.GetOrderHistory(id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.GetOrderHistory: %w", err)
+	}
+
+	history := []model.OrderVersion{}
+	for _, r := range results {
+		history = append(history, r...)
+	}
+	return history, nil
+}
+
+func (s *ShardedStorage) GetAllOrders(limit, offset int) ([]model.Order, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]model.Order, error) {
+		return shard.GetAllOrders(limit+offset, 0)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.GetAllOrders: %w", err)
+	}
+
+	merged := mergeSorted(results, func(a, b model.Order) bool {
+		return a.OrderUID < b.OrderUID
+	})
+	return page(merged, limit, offset), nil
+}
+
+func (s *ShardedStorage) GetOrdersBatch(ctx context.Context, batchSize int, processBatch func([]model.Order) error) error {
+	for i, shard := range s.shards {
+		if err := shard.GetOrdersBatch(ctx, batchSize, processBatch); err != nil {
+			return fmt.Errorf("storage.sharded.GetOrdersBatch: shard %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (s *ShardedStorage) SearchOrders(query string, limit, offset int) ([]model.SearchResult, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]model.SearchResult, error) {
+		return shard.SearchOrders(query, limit+offset, 0)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.SearchOrders: %w", err)
+	}
+
+	merged := mergeSorted(results, func(a, b model.SearchResult) bool {
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.DateCreated.Equal(b.DateCreated) {
+			return a.DateCreated.After(b.DateCreated)
+		}
+		return a.OrderUID < b.OrderUID
+	})
+	return page(merged, limit, offset), nil
+}
+
+// EraseCustomerData anonymizes on every shard and then logs once on shard 0.
+// Unlike the single-database case this is not atomic: if logging fails the
+// data is already erased, and running the erasure again is harmless.
+func (s *ShardedStorage) EraseCustomerData(req model.ErasureRequest) (model.ErasureRecord, error) {
+	const op = "storage.sharded.EraseCustomerData"
+
+	results, err := fanOut(s, func(shard *Storage) ([]string, error) {
+		return shard.AnonymizeCustomerData(req)
+	})
+	if err != nil {
+		return model.ErasureRecord{}, wrap(op, err)
+	}
+
+	var uids []string
+	for _, r := range results {
+		uids = append(uids, r...)
+	}
+	sort.Strings(uids)
+
+	record, err := s.shards[0].AppendErasureLog(req, uids)
+	if err != nil {
+		return model.ErasureRecord{}, wrap(op, err)
+	}
+	return record, nil
+}
+
+func (s *ShardedStorage) GetErasureLog() ([]model.ErasureRecord, error) {
+	return s.shards[0].GetErasureLog()
+}
+
+func (s *ShardedStorage) Close() error {
+	var errs []error
+	for _, shard := range s.shards {
+		errs = append(errs, shard.Close())
+	}
+	return errors.Join(errs...)
+}
+
+type RebalanceMove struct {
+	OrderUID string
+	From     int
+	To       int
+}
+
+// Rebalance moves every order that the current shard map places on a
+// different shard than the one holding it. With dryRun it only reports.
+func (s *ShardedStorage) Rebalance(ctx context.Context, dryRun bool, report func(RebalanceMove)) (int, error) {
+	const op = "storage.sharded.Rebalance"
+
+	moved := 0
+	for from, shard := range s.shards {
+		err := shard.ScanShardKeys(ctx, 500, func(uid, shardKey string) error {
+			to := s.shardMap.Shard(shardKey)
+			if to == from {
+				return nil
+			}
+
+			if !dryRun {
+				if err := s.move(uid, shard, s.shards[to]); err != nil {
+					return fmt.Errorf("order %s: %w", uid, err)
+				}
+			}
+
+			moved++
+			if report != nil {
+				report(RebalanceMove{OrderUID: uid, From: from, To: to})
+			}
+			return ctx.Err()
+		})
+		if err != nil {
+			return moved, fmt.Errorf("%s: shard %d: %w", op, from, err)
+		}
+	}
+
+	return moved, nil
+}
+
+// move copies an order with everything recorded about it to another shard,
+// replacing whatever copy is there, and only then deletes it at the source.
+// Stopping halfway leaves two copies; the next write or move settles them.
+func (s *ShardedStorage) move(uid string, from, to *Storage) error {
+	order, err := from.GetOrderById(uid)
+	if err != nil {
+		return err
+	}
+
+	history, err := from.GetOrderHistory(uid)
+	if err != nil {
+		return err
+	}
+
+	raws, err := from.GetRawOrders(uid)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := from.GetStatusHistory(context.Background(), uid)
+	if err != nil {
+		return err
+	}
+
+	if err := to.ImportOrder(order, history, raws, statuses); err != nil {
+		return err
+	}
+
+	return from.DeleteOrder(uid)
+}
+
+func fanOut[T any](s *ShardedStorage, fn func(*Storage) (T, error)) ([]T, error) {
+	results := make([]T, len(s.shards))
+	errs := make([]error, len(s.shards))
+
+	var wg sync.WaitGroup
+	for i, shard := range s.shards {
+		wg.Add(1)
+		go func(i int, shard *Storage) {
+			defer wg.Done()
+			results[i], errs[i] = fn(shard)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return results, nil
+}
+
+// findFirst returns the result of the shard that has the order. Not-found
+// from the other shards is expected and ignored.
+func findFirst[T any](s *ShardedStorage, fn func(*Storage) (T, error)) (T, error) {
+	results := make([]T, len(s.shards))
+	errs := make([]error, len(s.shards))
+
+	var wg sync.WaitGroup
+	for i, shard := range s.shards {
+		wg.Add(1)
+		go func(i int, shard *Storage) {
+			defer wg.Done()
+			results[i], errs[i] = fn(shard)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			return results[i], nil
+		}
+	}
+
+	var zero T
+	for i, err := range errs {
+		if !errors.Is(err, domain.ErrOrderNotFound) {
+			return zero, fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return zero, errs[0]
+}
+
+// mergeSorted merges lists that are each already sorted by less.
+func mergeSorted[T any](lists [][]T, less func(a, b T) bool) []T {
+	total := 0
+	for _, l := range lists {
+		total += len(l)
+	}
+
+	out := make([]T, 0, total)
+	pos := make([]int, len(lists))
+	for len(out) < total {
+		best := -1
+		for i, l := range lists {
+			if pos[i] == len(l) {
+				continue
+			}
+			if best == -1 || less(l[pos[i]], lists[best][pos[best]]) {
+				best = i
+			}
+		}
+		out = append(out, lists[best][pos[best]])
+		pos[best]++
+	}
+	return out
+}
+
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"l0/internal/config"
+	"l0/internal/domain"
+	"l0/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardMap(t *testing.T) {
+	modulo, err := NewShardMap(config.Sharding{Map: "modulo"}, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 1, modulo.Shard("9"))
+	assert.Equal(t, modulo.Shard("abc"), modulo.Shard("abc"))
+	assert.Less(t, modulo.Shard("abc"), 4)
+
+	static, err := NewShardMap(config.Sharding{Map: "static", Static: map[string]int{"9": 3}}, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 3, static.Shard("9"))
+	assert.Equal(t, 2, static.Shard("6"))
+
+	_, err = NewShardMap(config.Sharding{Map: "static", Static: map[string]int{"9": 4}}, 4)
+	assert.Error(t, err)
+
+	_, err = NewShardMap(config.Sharding{Map: "ring"}, 4)
+	assert.Error(t, err)
+}
+
+func TestMergeSortedPage(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+
+	merged := mergeSorted([][]int{{1, 4, 7}, {}, {2, 3, 9}, {5}}, less)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 7, 9}, merged)
+
+	assert.Equal(t, []int{3, 4}, page(merged, 2, 2))
+	assert.Equal(t, []int{9}, page(merged, 5, 6))
+	assert.Equal(t, []int{}, page(merged, 5, 10))
+}
+
+func TestShardedStorage(t *testing.T) {
+	shards := []*Storage{sqliteStorage(t), sqliteStorage(t)}
+	s := NewSharded(shards, ModuloMap{N: len(shards)})
+	ctx := context.Background()
+
+	base := testOrder(t)
+	created := base.DateCreated
+	orders := make([]model.Order, 4)
+	for i, uid := range []string{"a", "b", "c", "d"} {
+		order := base
+		order.OrderUID = uid
+		order.Payment.Transaction = uid
+		order.ShardKey = strconv.Itoa(i)
+		order.DateCreated = created.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, s.AddOrder(order, natsSource))
+		orders[i] = order
+	}
+
+	holders := func(uid string) []int {
+		var on []int
+		for i, shard := range shards {
+			ok, err := shard.HasOrder(uid)
+			require.NoError(t, err)
+			if ok {
+				on = append(on, i)
+			}
+		}
+		return on
+	}
+
+	t.Run("Routing", func(t *testing.T) {
+		assert.Equal(t, []int{0}, holders("a"))
+		assert.Equal(t, []int{1}, holders("b"))
+		assert.Equal(t, []int{0}, holders("c"))
+		assert.Equal(t, []int{1}, holders("d"))
+	})
+
+	t.Run("Point Reads", func(t *testing.T) {
+		got, err := s.GetOrderById("d")
+		require.NoError(t, err)
+		assert.Equal(t, "3", got.ShardKey)
+
+		_, err = s.GetOrderById("missing")
+		assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+	})
+
+	t.Run("Fan-out Reads", func(t *testing.T) {
+		page, err := s.ListOrders(ctx, OrderFilter{}, OrderPage{Limit: 3})
+		require.NoError(t, err)
+		require.Len(t, page, 3)
+		assert.Equal(t, []string{"d", "c", "b"}, []string{page[0].OrderUID, page[1].OrderUID, page[2].OrderUID})
+
+		page, err = s.ListOrders(ctx, OrderFilter{}, OrderPage{After: CursorAt(page[2]), Limit: 3})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, "a", page[0].OrderUID)
+
+		total, err := s.CountOrdersMatching(ctx, OrderFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, 4, total)
+
+		results, err := s.SearchOrders("Mascaras", 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, results, 4)
+	})
+
+	t.Run("Shardkey Change", func(t *testing.T) {
+		order := orders[0]
+		order.ShardKey = "1"
+		order.Entry = "WBIL2"
+		require.NoError(t, s.AddOrder(order, model.ChangeSource{Kind: model.SourceNATS, Ref: "2"}))
+
+		// The update lands next to the old version and then moves as a whole.
+		assert.Equal(t, []int{1}, holders("a"))
+
+		got, err := s.GetOrderById("a")
+		require.NoError(t, err)
+		assert.Equal(t, "WBIL2", got.Entry)
+
+		history, err := s.GetOrderHistory("a")
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, "2", history[1].Source.Ref)
+	})
+
+	t.Run("Interrupted Move", func(t *testing.T) {
+		// A move of c to shard 1 stopped after the import: both shards
+		// hold it, and the copy on shard 1 misses the update below.
+		history, err := shards[0].GetOrderHistory("c")
+		require.NoError(t, err)
+		require.NoError(t, shards[1].ImportOrder(orders[2], history, nil, nil))
+		assert.Equal(t, []int{0, 1}, holders("c"))
+
+		order := orders[2]
+		order.ShardKey = "3"
+		order.Entry = "WBIL3"
+		require.NoError(t, s.AddOrder(order, model.ChangeSource{Kind: model.SourceNATS, Ref: "3"}))
+		assert.Equal(t, []int{1}, holders("c"))
+
+		got, err := s.GetOrderById("c")
+		require.NoError(t, err)
+		assert.Equal(t, "WBIL3", got.Entry)
+
+		history, err = s.GetOrderHistory("c")
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+	})
+}
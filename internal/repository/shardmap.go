@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"fmt"
+	"hash/fnv"
+	"l0/internal/config"
+	"strconv"
+)
+
+// ShardMap decides which shard an order lives on from its shardkey.
+type ShardMap interface {
+	Shard(shardKey string) int
+}
+
+// ModuloMap spreads numeric shardkeys by remainder and hashes anything else.
+type ModuloMap struct {
+	N int
+}
+
+func (m ModuloMap) Shard(shardKey string) int {
+	if n, err := strconv.Atoi(shardKey); err == nil && n >= 0 {
+		return n % m.N
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(shardKey))
+	return int(h.Sum32() % uint32(m.N))
+}
+
+// StaticMap pins listed shardkeys to a shard and defers to Fallback for the
+// rest, so single hot keys can be moved without reshuffling everything.
+type StaticMap struct {
+	Assignments map[string]int
+	Fallback    ShardMap
+}
+
+func (m StaticMap) Shard(shardKey string) int {
+	if shard, ok := m.Assignments[shardKey]; ok {
+		return shard
+	}
+	return m.Fallback.Shard(shardKey)
+}
+
+func NewShardMap(cfg config.Sharding, shards int) (ShardMap, error) {
+	if shards <= 0 {
+		return nil, fmt.Errorf("shard map: no shards configured")
+	}
+
+	modulo := ModuloMap{N: shards}
+
+	switch cfg.Map {
+	case "", "modulo":
+		return modulo, nil
+	case "static":
+		for key, shard := range cfg.Static {
+			if shard < 0 || shard >= shards {
+				return nil, fmt.Errorf("shard map: shardkey %q assigned to shard %d, only %d shards configured", key, shard, shards)
+			}
+		}
+		return StaticMap{Assignments: cfg.Static, Fallback: modulo}, nil
+	default:
+		return nil, fmt.Errorf("shard map: unknown map %q", cfg.Map)
+	}
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// ConnectSQLite opens the embedded SQLite backend. It serves the same
+// Repository as Postgres and is meant for local runs and CI.
+func ConnectSQLite(path string) (*Storage, error) {
+	const op = "storage.sqlite.New"
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	if err := CheckSchemaVersion(db, DriverSQLite); err != nil {
+		db.Close()
+		return nil, wrap(op, err)
+	}
+
+	return &Storage{db: db, driver: DriverSQLite}, nil
+}
+
+func OpenSQLite(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	// Transactions take the write lock up front so that read-then-write
+	// transactions wait for each other instead of failing with SQLITE_BUSY.
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	return db, nil
+}
+
+func (s *Storage) forUpdate() string {
+	if s.driver == DriverSQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+func (s *Storage) selectOrder() string {
+	if s.driver == DriverSQLite {
+		return selectOrderQuerySQLite
+	}
+	return selectOrderQuery
+}
+
+const selectOrderQuerySQLite = `
+	SELECT o.order_uid, o.track_number, o.entry,
+		d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+		p."transaction", p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
+		p.bank, p.delivery_cost, p.goods_total, p.custom_fee,
+		o.locale, o.internal_signature, o.customer_id, o.delivery_service,
+		o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.status,
+		(
+			SELECT json_group_array(json_object(
+				'chrt_id', i.chrt_id, 'track_number', i.track_number, 'price', i.price, 'rid', i.rid,
+				'name', i.name, 'sale', i.sale, 'size', i.size, 'total_price', i.total_price,
+				'nm_id', i.nm_id, 'brand', i.brand, 'status', i.status
+			))
+			FROM (SELECT * FROM items WHERE order_uid = o.order_uid ORDER BY id) i
+		) AS items
+	FROM orders o
+	JOIN delivery d ON o.delivery_id = d.id
+	JOIN payment p ON o.payment_id = p.id`
+
+func (s *Storage) scrubHistorySQLite(tx *sql.Tx, uid string, patch []byte) error {
+	paths, err := json.Marshal(erasedPaths)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE order_history SET
+			snapshot = json_set(snapshot, '$.delivery', json(json_patch(COALESCE(json_extract(snapshot, '$.delivery'), '{}'), $2))),
+			diff = (
+				SELECT json_group_array(
+					CASE WHEN json_extract(c.value, '$.path') IN (SELECT value FROM json_each($3))
+						THEN json_object('path', json_extract(c.value, '$.path'))
+						ELSE json(c.value)
+					END
+				)
+				FROM json_each(order_history.diff) c
+			)
+		WHERE order_uid = $1`
+
+	if _, err := tx.Exec(query, uid, string(patch), string(paths)); err != nil {
+		return err
+	}
+
+	rawQuery := `
+		UPDATE order_raw_messages SET payload = json_set(payload, '$.delivery', json(json_patch(json_extract(payload, '$.delivery'), $2)))
+		WHERE order_uid = $1 AND json_type(payload, '$.delivery') = 'object'`
+
+	_, err = tx.Exec(rawQuery, uid, string(patch))
+	return err
+}
+
+func (s *Storage) refreshSearchSQLite(tx *sql.Tx, order_uid string) error {
+	if _, err := tx.Exec("DELETE FROM orders_fts WHERE order_uid = $1", order_uid); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO orders_fts (order_uid, names, brands, city)
+		SELECT o.order_uid,
+			COALESCE((SELECT group_concat(name, ' ') FROM items WHERE order_uid = o.order_uid), ''),
+			COALESCE((SELECT group_concat(DISTINCT brand) FROM items WHERE order_uid = o.order_uid), ''),
+			d.city
+		FROM orders o
+		JOIN delivery d ON d.id = o.delivery_id
+		WHERE o.order_uid = $1`
+
+	_, err := tx.Exec(query, order_uid)
+	return err
+}
+
+func (s *Storage) searchOrdersSQLite(query string, limit, offset int) (*sql.Rows, error) {
+	sqlQuery := `
+		SELECT o.order_uid, o.track_number, o.customer_id, o.date_created,
+			-bm25(orders_fts, 0.0, 1.0, 0.4, 0.2) AS rank,
+			snippet(orders_fts, -1, char(2), char(3), '…', 12) AS headline
+		FROM orders_fts
+		JOIN orders o ON o.order_uid = orders_fts.order_uid
+		WHERE orders_fts MATCH $1
+		ORDER BY rank DESC, o.date_created DESC, o.order_uid
+		LIMIT $2 OFFSET $3`
+
+	return s.db.Query(sqlQuery, ftsQuery(query), limit, offset)
+}
+
+// ftsQuery turns free text into an FTS5 query that matches all words, so
+// user input can't be parsed as FTS5 syntax.
+func ftsQuery(q string) string {
+	words := strings.Fields(q)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"l0/internal/domain"
+	"l0/internal/model"
+	"time"
+)
+
+// SetOrderStatus moves an order to a new status if its lifecycle allows it
+// and records the change in the status history.
+func (s *Storage) SetOrderStatus(ctx context.Context, id string, to model.OrderStatus, reason string) (_ model.StatusChange, err error) {
+	const op = "storage.postgres.SetOrderStatus"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.StatusChange{}, wrap(op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			err = wrap(op, err)
+		}
+	}()
+
+	change := model.StatusChange{OrderUID: id, To: to, Reason: reason, ChangedAt: time.Now().UTC()}
+
+	err = tx.QueryRowContext(ctx, "SELECT status FROM orders WHERE order_uid = $1"+s.forUpdate(), id).Scan(&change.From)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.StatusChange{}, fmt.Errorf("%s: %w", op, domain.Errorf(domain.ErrOrderNotFound, "order %s not found", id))
+	}
+	if err != nil {
+		return model.StatusChange{}, wrap(op, err)
+	}
+
+	if err = change.From.Transition(to); err != nil {
+		return model.StatusChange{}, wrap(op, err)
+	}
+
+	// SQLite has no row locks: the update only applies if nobody changed the
+	// status since it was read.
+	res, err := tx.ExecContext(ctx, "UPDATE orders SET status = $2, updated_at = $4 WHERE order_uid = $1 AND status = $3", id, to, change.From, change.ChangedAt)
+	if err != nil {
+		return model.StatusChange{}, wrap(op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return model.StatusChange{}, wrap(op, err)
+	}
+	if n == 0 {
+		err = fmt.Errorf("%s: %w", op, domain.Errorf(domain.ErrConflict, "order %s changed status concurrently, retry", id))
+		return model.StatusChange{}, err
+	}
+
+	if err = addStatusChange(tx, change); err != nil {
+		return model.StatusChange{}, wrap(op, err)
+	}
+
+	return change, nil
+}
+
+func addStatusChange(tx *sql.Tx, c model.StatusChange) error {
+	query := `INSERT INTO order_status_history (order_uid, from_status, to_status, reason, changed_at)
+			  VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := tx.Exec(query, c.OrderUID, c.From, c.To, c.Reason, c.ChangedAt.UTC())
+	return err
+}
+
+func (s *Storage) GetStatusHistory(ctx context.Context, id string) ([]model.StatusChange, error) {
+	const op = "storage.postgres.GetStatusHistory"
+
+	query := `
+		SELECT order_uid, from_status, to_status, reason, changed_at
+		FROM order_status_history
+		WHERE order_uid = $1
+		ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	defer rows.Close()
+
+	history := []model.StatusChange{}
+	for rows.Next() {
+		var c model.StatusChange
+		if err := rows.Scan(&c.OrderUID, &c.From, &c.To, &c.Reason, &c.ChangedAt); err != nil {
+			return nil, wrap(op, err)
+		}
+		c.ChangedAt = c.ChangedAt.UTC()
+		history = append(history, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return history, nil
+}
+
+func (s *ShardedStorage) SetOrderStatus(ctx context.Context, id string, to model.OrderStatus, reason string) (model.StatusChange, error) {
+	return findFirst(s, func(shard *Storage) (model.StatusChange, error) {
+		return shard.SetOrderStatus(ctx, id, to, reason)
+	})
+}
+
+func (s *ShardedStorage) GetStatusHistory(ctx context.Context, id string) ([]model.StatusChange, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]model.StatusChange, error) {
+		return shard.GetStatusHistory(ctx, id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.GetStatusHistory: %w", err)
+	}
+
+	history := []model.StatusChange{}
+	for _, r := range results {
+		history = append(history, r...)
+	}
+	return history, nil
+}
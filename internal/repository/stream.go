@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"l0/internal/model"
+	"strings"
+	"time"
+)
+
+// OrderCursor is a position in the newest-first order stream: the last order
+// that has been read. The zero cursor is the start of the stream.
+type OrderCursor struct {
+	DateCreated time.Time `json:"date_created"`
+	OrderUID    string    `json:"order_uid"`
+}
+
+func (c OrderCursor) IsZero() bool {
+	return c.OrderUID == ""
+}
+
+func CursorAt(o model.Order) OrderCursor {
+	return OrderCursor{DateCreated: o.DateCreated, OrderUID: o.OrderUID}
+}
+
+// newerThan is the newest-first order of the stream.
+func newerThan(a, b model.Order) bool {
+	if !a.DateCreated.Equal(b.DateCreated) {
+		return a.DateCreated.After(b.DateCreated)
+	}
+	return a.OrderUID > b.OrderUID
+}
+
+// GetOrdersNewestFirst returns up to limit orders that come after the cursor,
+// newest first. Pages are keyset-based, so orders inserted while the stream
+// is read never shift it.
+func (s *Storage) GetOrdersNewestFirst(ctx context.Context, after OrderCursor, limit int) ([]model.Order, error) {
+	const op = "storage.postgres.GetOrdersNewestFirst"
+
+	orders, err := s.ordersNewestFirst(ctx, after, limit, 1)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	return orders, nil
+}
+
+// SampleOrdersNewestFirst is GetOrdersNewestFirst over a random fraction of
+// the orders. The sample is drawn by the database, so only the sampled
+// orders are assembled and transferred.
+func (s *Storage) SampleOrdersNewestFirst(ctx context.Context, after OrderCursor, limit int, fraction float64) ([]model.Order, error) {
+	const op = "storage.postgres.SampleOrdersNewestFirst"
+
+	orders, err := s.ordersNewestFirst(ctx, after, limit, fraction)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	return orders, nil
+}
+
+// sampleScale is the resolution of SQLite samples, drawn from integers.
+const sampleScale = 1_000_000
+
+func (s *Storage) ordersNewestFirst(ctx context.Context, after OrderCursor, limit int, fraction float64) ([]model.Order, error) {
+	var conds []string
+	args := []any{limit}
+	if !after.IsZero() {
+		args = append(args, after.DateCreated.UTC(), after.OrderUID)
+		conds = append(conds, "(o.date_created < $2 OR (o.date_created = $2 AND o.order_uid < $3))")
+	}
+	if fraction > 0 && fraction < 1 {
+		if s.driver == DriverSQLite {
+			args = append(args, int64(fraction*sampleScale))
+			conds = append(conds, fmt.Sprintf("abs(random() %% %d) < $%d", sampleScale, len(args)))
+		} else {
+			args = append(args, fraction)
+			conds = append(conds, fmt.Sprintf("random() < $%d", len(args)))
+		}
+	}
+
+	query := s.selectOrder()
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $1"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]model.Order, 0, limit)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// ChangeCursor is a position in the oldest-first stream of order changes.
+// A cursor with only UpdatedAt set starts right after that time.
+type ChangeCursor struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	OrderUID  string    `json:"order_uid"`
+}
+
+// ChangedOrder is an order together with the time of its last write.
+type ChangedOrder struct {
+	Order     model.Order
+	UpdatedAt time.Time
+}
+
+func (c ChangedOrder) Cursor() ChangeCursor {
+	return ChangeCursor{UpdatedAt: c.UpdatedAt, OrderUID: c.Order.OrderUID}
+}
+
+func changedBefore(a, b ChangedOrder) bool {
+	if !a.UpdatedAt.Equal(b.UpdatedAt) {
+		return a.UpdatedAt.Before(b.UpdatedAt)
+	}
+	return a.Order.OrderUID < b.Order.OrderUID
+}
+
+// GetOrdersChangedSince returns up to limit orders written after the cursor,
+// least recently written first.
+func (s *Storage) GetOrdersChangedSince(ctx context.Context, after ChangeCursor, limit int) ([]ChangedOrder, error) {
+	const op = "storage.postgres.GetOrdersChangedSince"
+
+	// The write time is selected after the columns scanOrder reads.
+	query := strings.Replace(s.selectOrder(), "\n\tFROM orders o", ", o.updated_at\n\tFROM orders o", 1) +
+		` WHERE o.updated_at > $2 OR (o.updated_at = $2 AND o.order_uid > $3)
+		ORDER BY o.updated_at, o.order_uid LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, after.UpdatedAt.UTC(), after.OrderUID)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	defer rows.Close()
+
+	changed := make([]ChangedOrder, 0, limit)
+	for rows.Next() {
+		var c ChangedOrder
+		order, err := scanOrder(rows, &c.UpdatedAt)
+		if err != nil {
+			return nil, wrap(op, err)
+		}
+		c.Order = order
+		c.UpdatedAt = c.UpdatedAt.UTC()
+		changed = append(changed, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return changed, nil
+}
+
+func (s *Storage) CountOrders(ctx context.Context) (int, error) {
+	const op = "storage.postgres.CountOrders"
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders").Scan(&total); err != nil {
+		return 0, wrap(op, err)
+	}
+	return total, nil
+}
+
+func (s *ShardedStorage) GetOrdersNewestFirst(ctx context.Context, after OrderCursor, limit int) ([]model.Order, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]model.Order, error) {
+		return shard.GetOrdersNewestFirst(ctx, after, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.GetOrdersNewestFirst: %w", err)
+	}
+
+	return page(mergeSorted(results, newerThan), limit, 0), nil
+}
+
+func (s *ShardedStorage) SampleOrdersNewestFirst(ctx context.Context, after OrderCursor, limit int, fraction float64) ([]model.Order, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]model.Order, error) {
+		return shard.SampleOrdersNewestFirst(ctx, after, limit, fraction)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.SampleOrdersNewestFirst: %w", err)
+	}
+
+	return page(mergeSorted(results, newerThan), limit, 0), nil
+}
+
+func (s *ShardedStorage) GetOrdersChangedSince(ctx context.Context, after ChangeCursor, limit int) ([]ChangedOrder, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]ChangedOrder, error) {
+		return shard.GetOrdersChangedSince(ctx, after, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.GetOrdersChangedSince: %w", err)
+	}
+
+	return page(mergeSorted(results, changedBefore), limit, 0), nil
+}
+
+func (s *ShardedStorage) CountOrders(ctx context.Context) (int, error) {
+	counts, err := fanOut(s, func(shard *Storage) (int, error) {
+		return shard.CountOrders(ctx)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("storage.sharded.CountOrders: %w", err)
+	}
+
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	return total, nil
+}
+
+// ExistingOrderIDs returns the subset of ids that are stored.
+func (s *Storage) ExistingOrderIDs(ctx context.Context, ids []string) ([]string, error) {
+	const op = "storage.postgres.ExistingOrderIDs"
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT order_uid FROM orders WHERE order_uid IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	existing, err := scanStrings(rows)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+	return existing, nil
+}
+
+func (s *ShardedStorage) ExistingOrderIDs(ctx context.Context, ids []string) ([]string, error) {
+	results, err := fanOut(s, func(shard *Storage) ([]string, error) {
+		return shard.ExistingOrderIDs(ctx, ids)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.ExistingOrderIDs: %w", err)
+	}
+
+	var existing []string
+	for _, r := range results {
+		existing = append(existing, r...)
+	}
+	return existing, nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"l0/internal/model"
+)
+
+// ScanShardKeys calls fn for every order with its shardkey, one page at a
+// time, so fn may move or delete the orders it is handed.
+func (s *Storage) ScanShardKeys(ctx context.Context, pageSize int, fn func(orderUID, shardKey string) error) error {
+	const op = "storage.postgres.ScanShardKeys"
+
+	after := ""
+	for {
+		rows, err := s.db.QueryContext(ctx, "SELECT order_uid, shardkey FROM orders WHERE order_uid > $1 ORDER BY order_uid LIMIT $2", after, pageSize)
+		if err != nil {
+			return wrap(op, err)
+		}
+
+		type entry struct{ uid, key string }
+		var page []entry
+		for rows.Next() {
+			var e entry
+			if err := rows.Scan(&e.uid, &e.key); err != nil {
+				rows.Close()
+				return wrap(op, err)
+			}
+			page = append(page, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return wrap(op, err)
+		}
+
+		if len(page) == 0 {
+			return nil
+		}
+
+		for _, e := range page {
+			if err := fn(e.uid, e.key); err != nil {
+				return err
+			}
+		}
+		after = page[len(page)-1].uid
+	}
+}
+
+// ImportOrder writes an order together with its history, raw messages and
+// status changes as they were recorded elsewhere. A copy the shard already
+// holds, left by an interrupted move, is replaced: it may predate updates
+// made to the copy being imported.
+func (s *Storage) ImportOrder(ordr model.Order, history []model.OrderVersion, raws []model.RawOrder, statuses []model.StatusChange) (err error) {
+	const op = "storage.postgres.ImportOrder"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			err = wrap(op, err)
+		}
+	}()
+
+	err = deleteOrder(tx, ordr.OrderUID)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	err = s.insertOrder(tx, ordr)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	for _, v := range history {
+		err = importVersion(tx, v)
+		if err != nil {
+			return fmt.Errorf("%s: version %d: %w", op, v.Version, err)
+		}
+	}
+
+	for _, raw := range raws {
+		err = s.AddRawOrder(tx, raw)
+		if err != nil {
+			return wrap(op, err)
+		}
+	}
+
+	for _, c := range statuses {
+		err = addStatusChange(tx, c)
+		if err != nil {
+			return wrap(op, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) HasOrder(id string) (bool, error) {
+	exists, err := hasOrder(s.db, id)
+	if err != nil {
+		return false, wrap("storage.postgres.HasOrder", err)
+	}
+	return exists, nil
+}
+
+func hasOrder(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, id string) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM orders WHERE order_uid = $1)", id).Scan(&exists)
+	return exists, err
+}
+
+func importVersion(tx *sql.Tx, v model.OrderVersion) error {
+	changesJSON, err := json.Marshal(v.Diff)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO order_history (order_uid, version, source, source_ref, changed_at, diff, snapshot)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.Exec(query, v.OrderUID, v.Version, v.Source.Kind, v.Source.Ref, v.ChangedAt, string(changesJSON), string(v.Snapshot))
+	return err
+}
+
+func (s *Storage) DeleteOrder(id string) (err error) {
+	const op = "storage.postgres.DeleteOrder"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			err = wrap(op, err)
+		}
+	}()
+
+	err = deleteOrder(tx, id)
+	if err != nil {
+		return wrap(op, err)
+	}
+	return nil
+}
+
+// deleteOrder removes an order with its delivery and payment; the rest goes
+// with it by cascade. A missing order is not an error.
+func deleteOrder(tx *sql.Tx, id string) error {
+	var deliveryID, paymentID int64
+	err := tx.QueryRow("DELETE FROM orders WHERE order_uid = $1 RETURNING delivery_id, payment_id", id).Scan(&deliveryID, &paymentID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM delivery WHERE id = $1", deliveryID); err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM payment WHERE id = $1", paymentID)
+	return err
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"l0/internal/cache"
+	"l0/internal/domain"
+	"l0/internal/model"
+	"l0/internal/repository"
+)
+
+type ErasureService struct {
+	Storage repository.Repository
+	Cache   *cache.Orders
+	Local   *cache.Local[model.Order]
+}
+
+func NewErasureService(storage repository.Repository, orders *cache.Orders, local *cache.Local[model.Order]) *ErasureService {
+	return &ErasureService{
+		Storage: storage,
+		Cache:   orders,
+		Local:   local,
+	}
+}
+
+func (s *ErasureService) Erase(req model.ErasureRequest) (model.ErasureRecord, error) {
+	if req.SubjectID == "" {
+		return model.ErasureRecord{}, domain.Errorf(domain.ErrInvalid, "subject id is required")
+	}
+	if req.RequestedBy == "" {
+		return model.ErasureRecord{}, domain.Errorf(domain.ErrInvalid, "requested_by is required")
+	}
+
+	record, err := s.Storage.EraseCustomerData(req)
+	if err != nil {
+		return model.ErasureRecord{}, err
+	}
+
+	s.Local.Delete(record.OrderUIDs...)
+
+	// The erasure is already committed and logged, so a cache failure must not
+	// be reported as a failed erasure; the caller gets the record and the error.
+	if err := s.Cache.Delete(context.Background(), record.OrderUIDs...); err != nil {
+		return record, fmt.Errorf("erased in database but failed to purge cache: %w", err)
+	}
+
+	return record, nil
+}
+
+// VerifyLog walks the erasure log and reports the first entry whose hash
+// does not match its contents or whose link to the previous entry is broken.
+func (s *ErasureService) VerifyLog() (int, error) {
+	records, err := s.Storage.GetErasureLog()
+	if err != nil {
+		return 0, err
+	}
+
+	return model.VerifyErasureLog(records)
+}
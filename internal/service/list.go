@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"l0/internal/cache"
+	"l0/internal/domain"
+	"l0/internal/model"
+	"l0/internal/repository"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// indexChunk is how many ids a listing reads from a cache index at a time.
+const indexChunk = 100
+
+// ListOrders answers from the cache indexes when they can and from the
+// database otherwise.
+func (s *OrderService) ListOrders(ctx context.Context, f repository.OrderFilter, p repository.OrderPage) ([]model.Order, error) {
+	if orders, ok := s.listFromIndex(ctx, f, p); ok {
+		return orders, nil
+	}
+	return s.Storage.ListOrders(ctx, f, p)
+}
+
+// listFromIndex reads the index of the first field the listing filters by,
+// or the index of all orders. Every order found is read whole and checked
+// against the filter, so a stale index entry costs a lookup but never a
+// wrong row. A missing entry would cost a row, so the indexes are only used
+// while they are marked complete. They only reach back to IndexedSince: a
+// page is used when the listing starts after that, or when it is a
+// newest-first page that fills up before reaching it.
+func (s *OrderService) listFromIndex(ctx context.Context, f repository.OrderFilter, p repository.OrderPage) ([]model.Order, bool) {
+	q, ok := indexQuery(f)
+	if !ok || p.Limit <= 0 {
+		return nil, false
+	}
+	if !s.Cache.IndexesComplete(ctx) {
+		return nil, false
+	}
+
+	since := s.Cache.IndexedSince()
+	complete := since.IsZero() || !f.From.Before(since)
+	if !complete && p.Oldest {
+		return nil, false
+	}
+
+	q.From, q.To, q.Oldest = f.From, f.To, p.Oldest
+	if !p.After.IsZero() {
+		// Scores are whole milliseconds: the bound keeps the millisecond of
+		// the cursor and Follows drops the orders up to the cursor in it.
+		at := p.After.DateCreated.Truncate(time.Millisecond)
+		if p.Oldest && at.After(q.From) {
+			q.From = at
+		}
+		if end := at.Add(time.Millisecond); !p.Oldest && (q.To.IsZero() || end.Before(q.To)) {
+			q.To = end
+		}
+	}
+
+	orders := make([]model.Order, 0, p.Limit)
+	for len(orders) < p.Limit {
+		q.Limit = indexChunk
+		ids, err := s.Cache.Find(ctx, q)
+		if err != nil {
+			if !errors.Is(err, cache.ErrNoIndex) {
+				log.Warnf("failed to list orders from cache indexes: %v", err)
+			}
+			return nil, false
+		}
+
+		found, err := s.loadOrders(ctx, ids)
+		if err != nil {
+			log.Warnf("failed to load indexed orders: %v", err)
+			return nil, false
+		}
+		for _, order := range found {
+			if f.Matches(order) && p.Follows(order) {
+				orders = append(orders, order)
+			}
+		}
+
+		if len(ids) < indexChunk {
+			break
+		}
+		q.Offset += len(ids)
+	}
+
+	if len(orders) < p.Limit && !complete {
+		return nil, false
+	}
+	p.Sort(orders)
+	return orders[:min(len(orders), p.Limit)], true
+}
+
+// indexQuery picks the index a listing is read from. Listings by brand
+// alone would scan the index of all orders and are left to the database.
+func indexQuery(f repository.OrderFilter) (cache.IndexQuery, bool) {
+	for _, field := range []struct{ name, value string }{
+		{cache.IndexCustomer, f.CustomerID},
+		{cache.IndexTrackNumber, f.TrackNumber},
+		{cache.IndexDeliveryService, f.DeliveryService},
+	} {
+		if field.value != "" {
+			return cache.IndexQuery{Field: field.name, Value: field.value}, true
+		}
+	}
+	return cache.IndexQuery{}, f.Brand == ""
+}
+
+// loadOrders reads orders by id from the cache, loading those whose cached
+// value has expired like single ones. Ids the database no longer has are
+// skipped.
+func (s *OrderService) loadOrders(ctx context.Context, ids []string) ([]model.Order, error) {
+	cached, err := s.Cache.GetMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]model.Order, 0, len(ids))
+	for _, id := range ids {
+		order, ok := cached[id]
+		if !ok {
+			order, err = s.GetOrderById(id)
+			if errors.Is(err, domain.ErrOrderNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
@@ -0,0 +1,133 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"l0/internal/cache"
+	"l0/internal/config"
+	"l0/internal/lib/utils"
+	"l0/internal/model"
+	"l0/internal/repository"
+	"l0/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStorage counts the listings that reach the database.
+type countingStorage struct {
+	repository.Repository
+	lists int
+}
+
+func (s *countingStorage) ListOrders(ctx context.Context, f repository.OrderFilter, p repository.OrderPage) ([]model.Order, error) {
+	s.lists++
+	return s.Repository.ListOrders(ctx, f, p)
+}
+
+func TestListOrdersFromIndex(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "l0.db")
+	db, err := repository.OpenSQLite(path)
+	require.NoError(t, err)
+	require.NoError(t, repository.Migrate(db, repository.DriverSQLite, "up"))
+	db.Close()
+	storage, err := repository.ConnectSQLite(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { storage.Close() })
+
+	backend := cache.NewMemory()
+	cfg := config.Redis{
+		KeyPrefix:      "l0",
+		KeyVersion:     "v1",
+		Indexes:        true,
+		IndexRetention: 24 * time.Hour,
+		TTL:            config.CacheTTL{Default: time.Hour},
+	}
+	orders := cache.NewOrders(backend, cfg)
+	counting := &countingStorage{Repository: storage}
+	svc := service.New(counting, orders, nil)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	add := func(id, customer string, age time.Duration, cached bool) model.Order {
+		var order model.Order
+		require.NoError(t, json.Unmarshal([]byte(utils.TestOrder), &order))
+		order.OrderUID = id
+		order.CustomerID = customer
+		order.DateCreated = now.Add(-age)
+		require.NoError(t, storage.AddOrder(order, model.ChangeSource{Kind: model.SourceNATS}))
+
+		order, err := storage.GetOrderById(id)
+		require.NoError(t, err)
+		if cached {
+			require.NoError(t, orders.Set(ctx, order))
+		}
+		return order
+	}
+	a := add("a", "alice", 3*time.Hour, true)
+	b := add("b", "alice", 2*time.Hour, true)
+	add("c", "bob", time.Hour, true)
+	add("old", "alice", 48*time.Hour, false)
+	add("u", "dave", 4*time.Hour, false)
+
+	list := func(f repository.OrderFilter, p repository.OrderPage) []string {
+		t.Helper()
+		got, err := svc.ListOrders(ctx, f, p)
+		require.NoError(t, err)
+		ids := make([]string, len(got))
+		for i, o := range got {
+			ids[i] = o.OrderUID
+		}
+		return ids
+	}
+	alice := repository.OrderFilter{CustomerID: "alice"}
+
+	// Until a build has gone through every order, the indexes only know the
+	// cached ones, so listings are read from the database.
+	dave := repository.OrderFilter{CustomerID: "dave", From: now.Add(-5 * time.Hour)}
+	assert.Equal(t, []string{"u"}, list(dave, repository.OrderPage{Limit: 5}))
+	assert.Equal(t, 1, counting.lists)
+
+	require.NoError(t, cache.NewCacheService(orders, storage).Warmup(ctx))
+	require.True(t, orders.IndexesComplete(ctx))
+	counting.lists = 0
+
+	assert.Equal(t, []string{"u"}, list(dave, repository.OrderPage{Limit: 5}))
+	assert.Equal(t, []string{"b", "a"}, list(alice, repository.OrderPage{Limit: 2}))
+	assert.Equal(t, []string{"a"}, list(alice, repository.OrderPage{Limit: 1, After: repository.CursorAt(b)}))
+	assert.Equal(t, []string{"b"}, list(repository.OrderFilter{CustomerID: "alice", From: a.DateCreated.Add(time.Second)}, repository.OrderPage{Limit: 5}),
+		"the listing starts inside the window")
+	assert.Zero(t, counting.lists, "answered from the indexes")
+
+	// A page that reaches past the window is read from the database.
+	assert.Equal(t, []string{"b", "a", "old"}, list(alice, repository.OrderPage{Limit: 3}))
+	assert.Equal(t, []string{"old", "a"}, list(alice, repository.OrderPage{Limit: 2, Oldest: true}))
+	// So are listings by brand alone.
+	list(repository.OrderFilter{Brand: "Vivienne Sabo"}, repository.OrderPage{Limit: 1})
+	assert.Equal(t, 3, counting.lists)
+
+	// An order invalidated after a change is read again and filtered by its
+	// new fields, then indexed under them.
+	a.CustomerID = "carol"
+	require.NoError(t, storage.AddOrder(a, model.ChangeSource{Kind: model.SourceHTTP}))
+	require.NoError(t, orders.Delete(ctx, a.OrderUID))
+	recent := repository.OrderFilter{From: now.Add(-5 * time.Hour)}
+	recent.CustomerID = "alice"
+	assert.Equal(t, []string{"b"}, list(recent, repository.OrderPage{Limit: 5}))
+	recent.CustomerID = "carol"
+	assert.Equal(t, []string{"a"}, list(recent, repository.OrderPage{Limit: 5}))
+	assert.Equal(t, 3, counting.lists)
+
+	// A write that skips the indexes makes them incomplete again.
+	cfg.Write.Policy = cache.PolicyReadOnly
+	require.NoError(t, cache.NewOrders(backend, cfg).Set(ctx, b))
+	assert.False(t, orders.IndexesComplete(ctx))
+	recent.CustomerID = "alice"
+	assert.Equal(t, []string{"b"}, list(recent, repository.OrderPage{Limit: 5}))
+	assert.Equal(t, 4, counting.lists)
+}
@@ -1,48 +1,144 @@
 package service
 
 import (
+	"context"
 	"l0/internal/cache"
+	"l0/internal/config"
+	"l0/internal/domain"
 	"l0/internal/model"
 	"l0/internal/repository"
-	"sync"
 
 	"github.com/labstack/gommon/log"
 )
 
 type OrderService struct {
-	Storage *repository.Storage
-	Redis   *cache.Redis
+	Storage repository.Repository
+	Cache   *cache.Orders
+	Local   *cache.Local[model.Order]
+
+	flight *cache.Coalescer
 }
 
-func New(storage *repository.Storage, redis *cache.Redis) *OrderService {
+// New wires the order service. local may be nil when the in-process cache is
+// disabled.
+func New(storage repository.Repository, orders *cache.Orders, local *cache.Local[model.Order]) *OrderService {
 	return &OrderService{
 		Storage: storage,
-		Redis:   redis,
+		Cache:   orders,
+		Local:   local,
+		flight:  cache.NewCoalescer(orders),
 	}
 }
 
+func NewLocalCache(cfg config.LocalCache) *cache.Local[model.Order] {
+	return cache.NewLocal(cfg, orderSize)
+}
+
 func (s *OrderService) GetOrderById(id string) (model.Order, error) {
-	var order model.Order
+	if id == "" {
+		return model.Order{}, domain.Errorf(domain.ErrInvalid, "order id is required")
+	}
 
-	err := s.Redis.Get(id, &order)
-	if err == nil {
-		log.Info("got order from redis")
+	if order, ok := s.Local.Get(id); ok {
 		return order, nil
 	}
 
-	log.Info("got order from db")
+	order, err := s.Cache.Get(context.Background(), id)
+	if err == nil {
+		log.Info("got order from cache")
+		s.Local.Set(id, order)
+		return order, nil
+	}
 
-	order, err = s.Storage.GetOrderById(id)
+	// Concurrent misses of the same order share a single database load.
+	order, shared, err := s.flight.Order(id, func() (model.Order, error) {
+		log.Info("got order from db")
+		return s.Storage.GetOrderById(id)
+	})
 	if err != nil {
 		return order, err
 	}
+	if shared {
+		log.Info("got order from a concurrent load")
+	}
+
+	s.Local.Set(id, order)
+
+	return order, nil
+}
+
+// WatchInvalidations evicts the local copies of orders that other processes
+// change, until ctx is done.
+func (s *OrderService) WatchInvalidations(ctx context.Context) error {
+	return s.Cache.Invalidations(ctx, func(inv cache.Invalidation) {
+		if inv.Op == cache.OpFlush {
+			s.Local.Purge()
+			return
+		}
+		s.Local.Delete(inv.IDs...)
+	})
+}
+
+func (s *OrderService) CacheStats() cache.Stats {
+	return cache.Stats{
+		Local:        s.Local.Stats(),
+		NegativeHits: s.Cache.NegativeHits(),
+		Write:        s.Cache.WriteStats(),
+	}
+}
+
+// orderSize approximates the memory an order holds without encoding it.
+func orderSize(o model.Order) int {
+	const fixed = 256
+	const perItem = 96
+
+	d := o.Delivery
+	p := o.Payment
+	size := fixed + len(o.OrderUID) + len(o.TrackNumber) + len(o.Entry) + len(o.Locale) +
+		len(o.InternalSignature) + len(o.CustomerID) + len(o.DeliveryService) + len(o.ShardKey) + len(o.OOFShard) + len(o.Status) +
+		len(d.Name) + len(d.Phone) + len(d.Zip) + len(d.City) + len(d.Address) + len(d.Region) + len(d.Email) +
+		len(p.Transaction) + len(p.RequestID) + len(p.Provider) + len(p.Bank)
+	for _, it := range o.Items {
+		size += perItem + len(it.TrackNumber) + len(it.RID) + len(it.Name) + len(it.Size) + len(it.Brand)
+	}
+	return size
+}
+
+func (s *OrderService) GetOrderHistory(id string) ([]model.OrderVersion, error) {
+	return s.Storage.GetOrderHistory(id)
+}
+
+func (s *OrderService) GetRawOrder(id string) (model.RawOrder, error) {
+	return s.Storage.GetRawOrder(id)
+}
 
-	err = s.Redis.Set(id, order)
+func (s *OrderService) SearchOrders(query string, limit, offset int) ([]model.SearchResult, error) {
+	return s.Storage.SearchOrders(query, limit, offset)
+}
+
+// ChangeStatus moves an order through its lifecycle. The cached copies of
+// the order are dropped rather than rewritten: the delete reaches the other
+// replicas through the invalidation channel, and the next read caches the
+// order with its new status.
+func (s *OrderService) ChangeStatus(ctx context.Context, id string, to model.OrderStatus, reason string) (model.StatusChange, error) {
+	change, err := s.Storage.SetOrderStatus(ctx, id, to, reason)
 	if err != nil {
-		log.Warnf("failed to set order to redis: %v", err)
+		return change, err
 	}
 
-	return order, nil
+	s.Local.Delete(id)
+	if err := s.Cache.Delete(ctx, id); err != nil {
+		log.Errorf("failed to invalidate order %s in cache: %v", id, err)
+	}
+	return change, nil
+}
+
+func (s *OrderService) GetStatusHistory(ctx context.Context, id string) ([]model.StatusChange, error) {
+	return s.Storage.GetStatusHistory(ctx, id)
+}
+
+func (s *OrderService) CountOrders(ctx context.Context, f repository.OrderFilter) (int, error) {
+	return s.Storage.CountOrdersMatching(ctx, f)
 }
 
 func (s *OrderService) LoadOrdersToCache() error {
@@ -50,7 +146,6 @@ func (s *OrderService) LoadOrdersToCache() error {
 
 	const limit = 100
 	offset := 0
-	var wg sync.WaitGroup
 
 	for {
 		orders, err := s.Storage.GetAllOrders(limit, offset)
@@ -64,21 +159,13 @@ func (s *OrderService) LoadOrdersToCache() error {
 
 		log.Infof("loading %d orders to cache...", len(orders))
 
-		for _, order := range orders {
-			wg.Add(1)
-			go func(order model.Order) {
-				defer wg.Done()
-				err := s.Redis.Set(order.OrderUID, order)
-				if err != nil {
-					log.Warnf("failed to cache order %s: %v", order.OrderUID, err)
-				}
-			}(order)
+		if err := s.Cache.Warm(context.Background(), orders); err != nil {
+			log.Warnf("failed to cache %d orders: %v", len(orders), err)
 		}
 
 		offset += limit
 	}
 
-	wg.Wait()
 	log.Info("all orders loaded to cache")
 	return nil
 }
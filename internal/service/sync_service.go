@@ -2,52 +2,67 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"l0/internal/cache"
-	"l0/internal/model"
-	"l0/internal/repository"
-
-	"log"
+	"log/slog"
+	"sync"
 	"time"
 )
 
+// SyncService keeps the cache in line with the database by reconciling it
+// periodically. Only missing, stale and extra keys are touched.
 type SyncService struct {
-	db          *repository.Storage
 	cache       *cache.CacheService
-	batchSize   int
+	logger      *slog.Logger
 	syncTimeout time.Duration
+
+	mu   sync.Mutex
+	last cache.ReconcileStats
 }
 
-func NewSyncService(db *repository.Storage, cache *cache.CacheService, batchSize int, syncTimeout time.Duration) *SyncService {
+func NewSyncService(cache *cache.CacheService, logger *slog.Logger, syncTimeout time.Duration) *SyncService {
 	return &SyncService{
-		db:          db,
 		cache:       cache,
-		batchSize:   batchSize,
+		logger:      logger,
 		syncTimeout: syncTimeout,
 	}
 }
 
-func (s *SyncService) SyncData(ctx context.Context) error {
+func (s *SyncService) SyncData(ctx context.Context) (cache.ReconcileStats, error) {
 	ctx, cancel := context.WithTimeout(ctx, s.syncTimeout)
 	defer cancel()
 
-	if err := s.cache.ClearOldData(ctx, "*"); err != nil {
-		log.Printf("Failed to clear old data: %v", err)
+	stats, err := s.cache.Reconcile(ctx)
+	if err != nil {
+		return stats, err
 	}
 
-	processBatch := func(orders []model.Order) error {
-		var data []interface{}
-		for _, order := range orders {
-			data = append(data, order)
-		}
+	s.mu.Lock()
+	s.last = stats
+	s.mu.Unlock()
 
-		return s.cache.LoadDataBatch(ctx, data, func(item interface{}) string {
-			order := item.(model.Order)
-			return fmt.Sprintf("order:%s", order.OrderUID)
-		})
+	level := slog.LevelInfo
+	if stats.Drift() > 0 {
+		level = slog.LevelWarn
 	}
+	s.logger.Log(ctx, level, "cache reconciled",
+		slog.Time("since", stats.Since),
+		slog.Int("checked", stats.Checked),
+		slog.Int("scanned", stats.Scanned),
+		slog.Int("missing", stats.Missing),
+		slog.Int("stale", stats.Stale),
+		slog.Int("extra", stats.Extra),
+		slog.Duration("duration", stats.Duration),
+	)
+
+	return stats, nil
+}
+
+// LastStats returns the drift found by the last completed pass.
+func (s *SyncService) LastStats() cache.ReconcileStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return s.db.GetOrdersBatch(ctx, s.batchSize, processBatch)
+	return s.last
 }
 
 func (s *SyncService) StartPeriodicSync(ctx context.Context, interval time.Duration) {
@@ -59,8 +74,8 @@ func (s *SyncService) StartPeriodicSync(ctx context.Context, interval time.Durat
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := s.SyncData(ctx); err != nil {
-				log.Printf("Failed to sync data: %v", err)
+			if _, err := s.SyncData(ctx); err != nil {
+				s.logger.Error("failed to reconcile cache", slog.Any("error", err))
 			}
 		}
 	}
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS order_history (
+	id BIGSERIAL PRIMARY KEY,
+	order_uid VARCHAR(255) NOT NULL,
+	version INTEGER NOT NULL,
+	source VARCHAR(20) NOT NULL,
+	source_ref VARCHAR(255) NOT NULL DEFAULT '',
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	diff JSONB NOT NULL DEFAULT '[]'::jsonb,
+	snapshot JSONB NOT NULL,
+	UNIQUE (order_uid, version),
+	FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS order_history;
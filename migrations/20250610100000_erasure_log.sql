@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS erasure_log (
+	id BIGSERIAL PRIMARY KEY,
+	subject_type VARCHAR(20) NOT NULL,
+	subject_id VARCHAR(255) NOT NULL,
+	order_uids JSONB NOT NULL DEFAULT '[]'::jsonb,
+	requested_by VARCHAR(255) NOT NULL,
+	erased_at TIMESTAMPTZ NOT NULL,
+	prev_hash CHAR(64) NOT NULL,
+	hash CHAR(64) NOT NULL UNIQUE
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION erasure_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'erasure_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER erasure_log_no_update
+	BEFORE UPDATE OR DELETE ON erasure_log
+	FOR EACH ROW EXECUTE PROCEDURE erasure_log_append_only();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS erasure_log_no_update ON erasure_log;
+DROP FUNCTION IF EXISTS erasure_log_append_only();
+DROP TABLE IF EXISTS erasure_log;
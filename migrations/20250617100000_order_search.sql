@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS search_document TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS search_vector TSVECTOR NOT NULL DEFAULT ''::tsvector;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION refresh_order_search(uid VARCHAR) RETURNS void AS $$
+BEGIN
+	UPDATE orders o SET
+		search_document = concat_ws(' ', s.names, s.brands, d.city),
+		search_vector =
+			setweight(to_tsvector('simple', COALESCE(s.names, '')), 'A') ||
+			setweight(to_tsvector('simple', COALESCE(s.brands, '')), 'B') ||
+			setweight(to_tsvector('simple', d.city), 'C')
+	FROM delivery d,
+		LATERAL (
+			SELECT string_agg(i.name, ' ') AS names, string_agg(DISTINCT i.brand, ' ') AS brands
+			FROM items i
+			WHERE i.order_uid = uid
+		) s
+	WHERE o.order_uid = uid AND d.id = o.delivery_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+SELECT refresh_order_search(order_uid) FROM orders;
+
+CREATE INDEX IF NOT EXISTS orders_search_idx ON orders USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS orders_search_idx;
+DROP FUNCTION IF EXISTS refresh_order_search(VARCHAR);
+ALTER TABLE orders DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE orders DROP COLUMN IF EXISTS search_document;
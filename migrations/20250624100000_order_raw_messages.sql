@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS order_raw_messages (
+	id BIGSERIAL PRIMARY KEY,
+	order_uid VARCHAR(255) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	sequence BIGINT NOT NULL,
+	published_at TIMESTAMPTZ NOT NULL,
+	received_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	payload JSONB NOT NULL,
+	UNIQUE (subject, sequence),
+	FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS order_raw_messages_order_uid_idx ON order_raw_messages (order_uid, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS order_raw_messages;
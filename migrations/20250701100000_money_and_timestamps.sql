@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE payment
+	ALTER COLUMN amount TYPE BIGINT,
+	ALTER COLUMN delivery_cost TYPE BIGINT,
+	ALTER COLUMN goods_total TYPE BIGINT,
+	ALTER COLUMN custom_fee TYPE BIGINT,
+	ADD COLUMN IF NOT EXISTS payment_dt TIMESTAMPTZ NOT NULL DEFAULT to_timestamp(0);
+
+ALTER TABLE items
+	ALTER COLUMN price TYPE BIGINT,
+	ALTER COLUMN total_price TYPE BIGINT;
+
+ALTER TABLE orders
+	ALTER COLUMN date_created TYPE TIMESTAMPTZ USING date_created AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE orders
+	ALTER COLUMN date_created TYPE TIMESTAMP USING date_created AT TIME ZONE 'UTC';
+
+ALTER TABLE items
+	ALTER COLUMN price TYPE INTEGER,
+	ALTER COLUMN total_price TYPE INTEGER;
+
+ALTER TABLE payment
+	DROP COLUMN IF EXISTS payment_dt,
+	ALTER COLUMN amount TYPE INTEGER,
+	ALTER COLUMN delivery_cost TYPE INTEGER,
+	ALTER COLUMN goods_total TYPE INTEGER,
+	ALTER COLUMN custom_fee TYPE INTEGER;
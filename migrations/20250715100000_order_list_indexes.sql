@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS orders_date_created_idx ON orders (date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS orders_customer_id_idx ON orders (customer_id, date_created DESC);
+CREATE INDEX IF NOT EXISTS orders_track_number_idx ON orders (track_number);
+CREATE INDEX IF NOT EXISTS orders_delivery_service_idx ON orders (delivery_service, date_created DESC);
+CREATE INDEX IF NOT EXISTS items_brand_idx ON items (brand, order_uid);
+
+-- +goose Down
+DROP INDEX IF EXISTS items_brand_idx;
+DROP INDEX IF EXISTS orders_delivery_service_idx;
+DROP INDEX IF EXISTS orders_track_number_idx;
+DROP INDEX IF EXISTS orders_customer_id_idx;
+DROP INDEX IF EXISTS orders_date_created_idx;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Amounts were stored as the feed sends them, in whole units of the payment
+-- currency; the model now holds minor units. Every currency in
+-- model.minorUnits has two decimals; amounts in unknown currencies stay as
+-- they are, like the model keeps them.
+UPDATE items SET
+	price = price * 100,
+	total_price = total_price * 100
+WHERE order_uid IN (
+	SELECT o.order_uid FROM orders o JOIN payment p ON p.id = o.payment_id
+	WHERE upper(p.currency) IN ('USD', 'EUR', 'RUB', 'BYN', 'KZT', 'KGS', 'AMD', 'UZS', 'GEL', 'CNY', 'TRY')
+);
+
+UPDATE payment SET
+	amount = amount * 100,
+	delivery_cost = delivery_cost * 100,
+	goods_total = goods_total * 100,
+	custom_fee = custom_fee * 100
+WHERE upper(currency) IN ('USD', 'EUR', 'RUB', 'BYN', 'KZT', 'KGS', 'AMD', 'UZS', 'GEL', 'CNY', 'TRY');
+
+-- +goose Down
+UPDATE items SET
+	price = price / 100,
+	total_price = total_price / 100
+WHERE order_uid IN (
+	SELECT o.order_uid FROM orders o JOIN payment p ON p.id = o.payment_id
+	WHERE upper(p.currency) IN ('USD', 'EUR', 'RUB', 'BYN', 'KZT', 'KGS', 'AMD', 'UZS', 'GEL', 'CNY', 'TRY')
+);
+
+UPDATE payment SET
+	amount = amount / 100,
+	delivery_cost = delivery_cost / 100,
+	goods_total = goods_total / 100,
+	custom_fee = custom_fee / 100
+WHERE upper(currency) IN ('USD', 'EUR', 'RUB', 'BYN', 'KZT', 'KGS', 'AMD', 'UZS', 'GEL', 'CNY', 'TRY');
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE orders SET updated_at = date_created;
+ALTER TABLE orders
+	ALTER COLUMN updated_at SET NOT NULL,
+	ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS orders_updated_at_idx ON orders (updated_at, order_uid);
+
+-- +goose Down
+DROP INDEX IF EXISTS orders_updated_at_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS updated_at;
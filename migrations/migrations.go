@@ -0,0 +1,13 @@
+package migrations
+
+import "embed"
+
+// FS holds the Postgres migrations.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteFS holds the equivalent schema for the SQLite backend under sqlite/.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
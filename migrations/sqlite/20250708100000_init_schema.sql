@@ -0,0 +1,143 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS delivery (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	phone TEXT NOT NULL,
+	zip TEXT NOT NULL,
+	city TEXT NOT NULL,
+	address TEXT NOT NULL,
+	region TEXT NOT NULL,
+	email TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS payment (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	"transaction" TEXT NOT NULL,
+	request_id TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	provider TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	payment_dt DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',
+	bank TEXT NOT NULL,
+	delivery_cost INTEGER NOT NULL,
+	goods_total INTEGER NOT NULL,
+	custom_fee INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+	order_uid TEXT PRIMARY KEY,
+	track_number TEXT NOT NULL,
+	entry TEXT NOT NULL,
+	delivery_id INTEGER NOT NULL,
+	payment_id INTEGER NOT NULL,
+	locale TEXT NOT NULL,
+	internal_signature TEXT NOT NULL,
+	customer_id TEXT NOT NULL,
+	delivery_service TEXT NOT NULL,
+	shardkey TEXT NOT NULL,
+	sm_id INTEGER NOT NULL,
+	oof_shard TEXT NOT NULL,
+	date_created DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_uid TEXT NOT NULL,
+	chrt_id INTEGER NOT NULL,
+	track_number TEXT NOT NULL,
+	price INTEGER NOT NULL,
+	rid TEXT NOT NULL,
+	name TEXT NOT NULL,
+	sale INTEGER NOT NULL,
+	size TEXT NOT NULL,
+	total_price INTEGER NOT NULL,
+	nm_id INTEGER NOT NULL,
+	brand TEXT NOT NULL,
+	status INTEGER NOT NULL,
+	FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS delivery_id_idx ON orders (delivery_id);
+CREATE INDEX IF NOT EXISTS payment_id_idx ON orders (payment_id);
+CREATE INDEX IF NOT EXISTS items_idx ON items (rid);
+CREATE INDEX IF NOT EXISTS items_order_uid_idx ON items (order_uid);
+
+CREATE TABLE IF NOT EXISTS order_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_uid TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	source TEXT NOT NULL,
+	source_ref TEXT NOT NULL DEFAULT '',
+	changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	diff TEXT NOT NULL DEFAULT '[]',
+	snapshot TEXT NOT NULL,
+	UNIQUE (order_uid, version),
+	FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS erasure_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	subject_type TEXT NOT NULL,
+	subject_id TEXT NOT NULL,
+	order_uids TEXT NOT NULL DEFAULT '[]',
+	requested_by TEXT NOT NULL,
+	erased_at DATETIME NOT NULL,
+	prev_hash TEXT NOT NULL,
+	hash TEXT NOT NULL UNIQUE
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS erasure_log_no_update BEFORE UPDATE ON erasure_log
+BEGIN
+	SELECT RAISE(ABORT, 'erasure_log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS erasure_log_no_delete BEFORE DELETE ON erasure_log
+BEGIN
+	SELECT RAISE(ABORT, 'erasure_log is append-only');
+END;
+-- +goose StatementEnd
+
+CREATE VIRTUAL TABLE IF NOT EXISTS orders_fts USING fts5(
+	order_uid UNINDEXED,
+	names,
+	brands,
+	city,
+	tokenize = 'unicode61'
+);
+
+CREATE TABLE IF NOT EXISTS order_raw_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_uid TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	sequence INTEGER NOT NULL,
+	published_at DATETIME NOT NULL,
+	received_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	payload TEXT NOT NULL,
+	UNIQUE (subject, sequence),
+	FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS order_raw_messages_order_uid_idx ON order_raw_messages (order_uid, id);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS orders_fts_delete AFTER DELETE ON orders
+BEGIN
+	DELETE FROM orders_fts WHERE order_uid = old.order_uid;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS orders_fts_delete;
+DROP TABLE IF EXISTS order_raw_messages;
+DROP TABLE IF EXISTS orders_fts;
+DROP TRIGGER IF EXISTS erasure_log_no_delete;
+DROP TRIGGER IF EXISTS erasure_log_no_update;
+DROP TABLE IF EXISTS erasure_log;
+DROP TABLE IF EXISTS order_history;
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS delivery;
+DROP TABLE IF EXISTS payment;
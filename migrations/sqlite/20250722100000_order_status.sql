@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE orders ADD COLUMN status TEXT NOT NULL DEFAULT 'created';
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_uid TEXT NOT NULL,
+	from_status TEXT NOT NULL,
+	to_status TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_uid_idx ON order_status_history (order_uid, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS order_status_history;
+ALTER TABLE orders DROP COLUMN status;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- ADD COLUMN only takes constant defaults.
+ALTER TABLE orders ADD COLUMN updated_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+UPDATE orders SET updated_at = date_created;
+
+CREATE INDEX IF NOT EXISTS orders_updated_at_idx ON orders (updated_at, order_uid);
+
+-- +goose Down
+DROP INDEX IF EXISTS orders_updated_at_idx;
+ALTER TABLE orders DROP COLUMN updated_at;